@@ -1,3 +1,5 @@
+//go:build !visualisation
+
 package main
 
 import (
@@ -35,6 +37,11 @@ func main() {
 	beta := flag.Float64("beta", 1.0, "beta weight for best insertion cost")
 	maxRuns := flag.Int("maxruns", 200, "maximum runs per method")
 	verbose := flag.Bool("verbose", false, "print verbose output")
+	regretK := flag.Int("regretk", 2, "k of the k-regret (number of cheapest insertions compared)")
+	sweep := flag.Bool("sweep", false, "sweep k and (alpha,beta) instead of the default run")
+	sweepK := flag.String("sweepk", "2,3,4,5", "comma-separated k values for -sweep")
+	sweepW := flag.String("sweepw", "1:0,1:0.5,1:1,0.5:1,0:1", "comma-separated alpha:beta pairs for -sweep (the pure regret rule is always included)")
+	sweepOut := flag.String("sweepout", "regret_sweep.csv", "output CSV of -sweep")
 	flag.Parse()
 
 	if *inFile == "" {
@@ -50,6 +57,25 @@ func main() {
 	fmt.Printf("Loaded %d nodes. Selecting k=%d per tour.\n", n, k)
 
 	D := computeDistanceMatrix(nodes)
+	if *regretK < 1 {
+		log.Fatal("-regretk must be at least 1")
+	}
+
+	if *sweep {
+		ks, err := parseInts(*sweepK)
+		if err != nil {
+			log.Fatalf("bad -sweepk: %v", err)
+		}
+		weights, err := parseWeights(*sweepW)
+		if err != nil {
+			log.Fatalf("bad -sweepw: %v", err)
+		}
+		if err := runSweep(D, nodes, k, min(*maxRuns, n), ks, weights, *sweepOut); err != nil {
+			log.Fatalf("failed writing sweep: %v", err)
+		}
+		return
+	}
+
 	methods := []string{"regret", "weighted"}
 
 	var bestResults []Solution
@@ -65,11 +91,11 @@ func main() {
 			var sol Solution
 			switch m {
 			case "regret":
-				sol = greedyRegret(D, nodes, k, start, *verbose)
-				sol.Method = "2-Regret insertion"
+				sol = greedyRegret(D, nodes, k, start, *regretK, *verbose)
+				sol.Method = fmt.Sprintf("%d-Regret insertion", *regretK)
 			case "weighted":
-				sol = greedyWeighted(D, nodes, k, start, *alpha, *beta, *verbose)
-				sol.Method = fmt.Sprintf("Weighted (α=%.2f,β=%.2f)", *alpha, *beta)
+				sol = greedyWeighted(D, nodes, k, start, *regretK, *alpha, *beta, *verbose)
+				sol.Method = weightedName(*regretK, *alpha, *beta)
 			}
 			sol.StartNode = start
 			allObjs = append(allObjs, sol.Obj)
@@ -248,14 +274,12 @@ func countSelected(sel []bool) int {
 
 // --- Greedy methods ---
 
-func greedyRegret(D [][]int, nodes []Node, k int, start int, verbose bool) Solution {
-	n := len(nodes)
-	selected := make([]bool, n)
+// initialPair starts the tour with `start` and the node closest to it (distance + cost)
+func initialPair(D [][]int, nodes []Node, start int, selected []bool) []int {
 	selected[start] = true
-	// second node: closest to start
 	bestJ := -1
 	bestVal := math.MaxInt
-	for j := 0; j < n; j++ {
+	for j := range nodes {
 		if j == start {
 			continue
 		}
@@ -266,104 +290,321 @@ func greedyRegret(D [][]int, nodes []Node, k int, start int, verbose bool) Solut
 		}
 	}
 	selected[bestJ] = true
-	tour := []int{start, bestJ}
+	return []int{start, bestJ}
+}
 
-	for countSelected(selected) < k {
+// greedyRegret inserts the node with the largest r-regret (sum of differences between its
+// j-th and cheapest insertion, j = 2..r), ties broken by the cheaper insertion
+func greedyRegret(D [][]int, nodes []Node, k int, start int, r int, verbose bool) Solution {
+	n := len(nodes)
+	selected := make([]bool, n)
+	tour := initialPair(D, nodes, start, selected)
+
+	cache := newInsertionCache(D, nodes, tour, selected, r)
+	for len(cache.tour) < k {
 		type cand struct {
-			node, bestTot, secondTot, bestPos int
+			node, bestTot, regret, bestPos int
 		}
 		var cands []cand
 		for v := 0; v < n; v++ {
 			if selected[v] {
 				continue
 			}
-			bestInc, bestPos := bestInsertion(v, tour, D)
-			secondInc := math.MaxInt
-			for i := 0; i < len(tour); i++ {
-				a := tour[i]
-				b := tour[(i+1)%len(tour)]
-				inc := D[a][v] + D[v][b] - D[a][b]
-				if inc < secondInc && i+1 != bestPos {
-					secondInc = inc
-				}
-			}
-			if secondInc == math.MaxInt {
-				secondInc = bestInc
-			}
-			bestTot := bestInc + nodes[v].Cost
-			secondTot := secondInc + nodes[v].Cost
-			cands = append(cands, cand{v, bestTot, secondTot, bestPos})
-		}
-		sort.Slice(cands, func(a, b int) bool {
-			regA := cands[a].secondTot - cands[a].bestTot
-			regB := cands[b].secondTot - cands[b].bestTot
-			if regA != regB {
-				return regA > regB
+			cands = append(cands, cand{v, cache.bestTot(v), cache.regret(v), cache.bestPos(v)})
+		}
+		ch := firstAfterSort(cands, func(a, b cand) bool {
+			if a.regret != b.regret {
+				return a.regret > b.regret
 			}
-			return cands[a].bestTot < cands[b].bestTot
+			return a.bestTot < b.bestTot
 		})
-		ch := cands[0]
-		selected[ch.node] = true
-		tour = insertAt(tour, ch.bestPos, ch.node)
+		cache.insert(ch.node, ch.bestPos)
 	}
+	tour = cache.tour
 	return finalizeSolution("2-Regret", D, nodes, selected, tour)
 }
 
-func greedyWeighted(D [][]int, nodes []Node, k int, start int, alpha, beta float64, verbose bool) Solution {
+// greedyWeighted inserts the node maximising alpha*regret - beta*(cheapest insertion cost),
+// with the r-regret of greedyRegret
+func greedyWeighted(D [][]int, nodes []Node, k int, start int, r int, alpha, beta float64, verbose bool) Solution {
 	n := len(nodes)
 	selected := make([]bool, n)
-	selected[start] = true
-	// pick second node: nearest neighbor
-	bestJ := -1
-	bestVal := math.MaxInt
-	for j := 0; j < n; j++ {
-		if j == start {
-			continue
-		}
-		val := D[start][j] + nodes[j].Cost
-		if val < bestVal {
-			bestVal = val
-			bestJ = j
-		}
-	}
-	selected[bestJ] = true
-	tour := []int{start, bestJ}
+	tour := initialPair(D, nodes, start, selected)
 
-	for countSelected(selected) < k {
+	cache := newInsertionCache(D, nodes, tour, selected, r)
+	for len(cache.tour) < k {
 		type cand struct {
-			node, bestTot, secondTot, bestPos int
-			score                             float64
+			node, bestTot, regret, bestPos int
+			score                          float64
 		}
 		var cands []cand
 		for v := 0; v < n; v++ {
 			if selected[v] {
 				continue
 			}
-			bestInc, bestPos := bestInsertion(v, tour, D)
-			secondInc := math.MaxInt
-			for i := 0; i < len(tour); i++ {
-				a := tour[i]
-				b := tour[(i+1)%len(tour)]
-				inc := D[a][v] + D[v][b] - D[a][b]
-				if inc < secondInc && i+1 != bestPos {
-					secondInc = inc
+			bestTot := cache.bestTot(v)
+			regret := cache.regret(v)
+			score := alpha*float64(regret) - beta*float64(bestTot)
+			cands = append(cands, cand{v, bestTot, regret, cache.bestPos(v), score})
+		}
+		ch := firstAfterSort(cands, func(a, b cand) bool { return a.score > b.score })
+		cache.insert(ch.node, ch.bestPos)
+	}
+	tour = cache.tour
+	return finalizeSolution("Weighted", D, nodes, selected, tour)
+}
+
+// --- incremental insertion costs ---
+//
+// For every unselected node the cache keeps its r cheapest insertions (edge increase)
+// and the edges they refer to, sorted by increase. Inserting w into a -> b replaces that
+// edge by a -> w and w -> b, so only nodes with a cached insertion into a -> b are
+// rescanned; the others compare the two new edges with their cached values. Ties for the
+// cheapest insertion go to the earliest tour position, exactly like bestInsertion.
+
+type insertion struct {
+	inc, from, to int
+}
+
+type insertionCache struct {
+	D        [][]int
+	nodes    []Node
+	tour     []int
+	selected []bool
+	pos      []int         // tour position of every selected node
+	r        int           // number of insertions kept per node
+	ins      [][]insertion // r cheapest insertions of every unselected node
+}
+
+func newInsertionCache(D [][]int, nodes []Node, tour []int, selected []bool, r int) *insertionCache {
+	n := len(nodes)
+	c := &insertionCache{
+		D: D, nodes: nodes, tour: tour, selected: selected, pos: make([]int, n),
+		r: r, ins: make([][]insertion, n),
+	}
+	for i, v := range tour {
+		c.pos[v] = i
+	}
+	for v := 0; v < n; v++ {
+		if !selected[v] {
+			c.ins[v] = make([]insertion, 0, r)
+			c.refresh(v)
+		}
+	}
+	return c
+}
+
+// refresh rescans the whole tour for node v
+func (c *insertionCache) refresh(v int) {
+	m := len(c.tour)
+	c.ins[v] = c.ins[v][:0]
+	for i := 0; i < m; i++ {
+		c.offer(v, c.tour[i], c.tour[(i+1)%m])
+	}
+}
+
+// offer compares the new edge from -> to with the cached insertions of v
+func (c *insertionCache) offer(v int, from int, to int) {
+	inc := c.D[from][v] + c.D[v][to] - c.D[from][to]
+	list := c.ins[v]
+	j := len(list)
+	for j > 0 && inc < list[j-1].inc {
+		j--
+	}
+	// entries 0..j-1 all equal inc here, the earliest position goes first
+	if j > 0 && inc == list[0].inc && c.pos[from] < c.pos[list[0].from] {
+		j = 0
+	}
+	if j == c.r {
+		return
+	}
+	if len(list) < c.r {
+		list = append(list, insertion{})
+	}
+	copy(list[j+1:], list[j:])
+	list[j] = insertion{inc, from, to}
+	c.ins[v] = list
+}
+
+// insert puts node at tour position at, between tour[at-1] and tour[at]
+func (c *insertionCache) insert(node int, at int) {
+	m := len(c.tour)
+	a := c.tour[at-1]
+	b := c.tour[at%m]
+	c.tour = insertAt(c.tour, at, node)
+	c.selected[node] = true
+	for i := at; i < len(c.tour); i++ {
+		c.pos[c.tour[i]] = i
+	}
+	for v := range c.nodes {
+		if c.selected[v] {
+			continue
+		}
+		stale := false
+		for _, e := range c.ins[v] {
+			if e.from == a && e.to == b {
+				stale = true
+				break
+			}
+		}
+		if stale {
+			c.refresh(v)
+			continue
+		}
+		c.offer(v, a, node)
+		c.offer(v, node, b)
+	}
+}
+
+func (c *insertionCache) bestTot(v int) int {
+	return c.ins[v][0].inc + c.nodes[v].Cost
+}
+
+// regret is the k-regret of v: the sum of differences between its j-th and cheapest
+// insertion, j = 2..r. Insertions missing on a short tour count as the cheapest one.
+func (c *insertionCache) regret(v int) int {
+	sum := 0
+	for _, e := range c.ins[v][1:] {
+		sum += e.inc - c.ins[v][0].inc
+	}
+	return sum
+}
+
+func (c *insertionCache) bestPos(v int) int {
+	return c.pos[c.ins[v][0].from] + 1
+}
+
+// firstAfterSort returns the element sort.Slice would put first. A linear scan suffices
+// unless several elements tie for the first place; then the sort is done to pick the same one.
+func firstAfterSort[T any](cands []T, less func(a, b T) bool) T {
+	first := cands[0]
+	tie := false
+	for _, x := range cands[1:] {
+		if less(x, first) {
+			first, tie = x, false
+		} else if !less(first, x) {
+			tie = true
+		}
+	}
+	if tie {
+		sort.Slice(cands, func(a, b int) bool { return less(cands[a], cands[b]) })
+		first = cands[0]
+	}
+	return first
+}
+
+// --- k-regret sweep ---
+
+// weightedName keeps the name used in the report for the 2-regret default
+func weightedName(r int, alpha, beta float64) string {
+	if r == 2 {
+		return fmt.Sprintf("Weighted (α=%.2f,β=%.2f)", alpha, beta)
+	}
+	return fmt.Sprintf("Weighted %d-regret (α=%.2f,β=%.2f)", r, alpha, beta)
+}
+
+// runSweep runs the pure k-regret rule and every alpha:beta weighting for each k from
+// the first `count` start nodes and writes best/worst/average objectives per setting
+func runSweep(D [][]int, nodes []Node, k int, count int, ks []int, weights [][2]float64, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	defer w.Flush()
+	if err := w.Write([]string{"Method", "K", "Alpha", "Beta", "Best", "Worst", "Average", "BestStart"}); err != nil {
+		return err
+	}
+
+	for _, r := range ks {
+		if r < 1 {
+			return fmt.Errorf("k must be at least 1, got %d", r)
+		}
+		// the nil setting stands for the lexicographic rule of greedyRegret
+		settings := [][]float64{nil}
+		for _, wt := range weights {
+			settings = append(settings, []float64{wt[0], wt[1]})
+		}
+		for _, set := range settings {
+			best, worst, avg := runStarts(count, func(start int) Solution {
+				if set == nil {
+					return greedyRegret(D, nodes, k, start, r, false)
 				}
+				return greedyWeighted(D, nodes, k, start, r, set[0], set[1], false)
+			})
+			name, alpha, beta := fmt.Sprintf("%d-Regret insertion", r), "", ""
+			if set != nil {
+				name = weightedName(r, set[0], set[1])
+				alpha = strconv.FormatFloat(set[0], 'g', -1, 64)
+				beta = strconv.FormatFloat(set[1], 'g', -1, 64)
 			}
-			if secondInc == math.MaxInt {
-				secondInc = bestInc
+			fmt.Printf("%-40s best %d  avg %.2f  worst %d\n", name, best.Obj, avg, worst)
+			row := []string{
+				name,
+				strconv.Itoa(r),
+				alpha,
+				beta,
+				strconv.Itoa(best.Obj),
+				strconv.Itoa(worst),
+				strconv.FormatFloat(avg, 'f', 2, 64),
+				strconv.Itoa(best.StartNode),
+			}
+			if err := w.Write(row); err != nil {
+				return err
 			}
-			bestTot := bestInc + nodes[v].Cost
-			secondTot := secondInc + nodes[v].Cost
-			regret := secondTot - bestTot
-			score := alpha*float64(regret) - beta*float64(bestTot)
-			cands = append(cands, cand{v, bestTot, secondTot, bestPos, score})
 		}
-		sort.Slice(cands, func(a, b int) bool { return cands[a].score > cands[b].score })
-		ch := cands[0]
-		selected[ch.node] = true
-		tour = insertAt(tour, ch.bestPos, ch.node)
 	}
-	return finalizeSolution("Weighted", D, nodes, selected, tour)
+	return w.Error()
+}
+
+// runStarts builds a solution from each of the first `count` start nodes
+func runStarts(count int, build func(start int) Solution) (best Solution, worst int, avg float64) {
+	var objs []int
+	best = Solution{Obj: math.MaxInt}
+	for start := 0; start < count; start++ {
+		sol := build(start)
+		sol.StartNode = start
+		objs = append(objs, sol.Obj)
+		if sol.Obj < best.Obj {
+			best = sol
+		}
+	}
+	worst, avg = stats(objs)
+	return
+}
+
+func parseInts(s string) ([]int, error) {
+	var out []int
+	for _, part := range strings.Split(s, ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// parseWeights reads "alpha:beta,alpha:beta,..."
+func parseWeights(s string) ([][2]float64, error) {
+	var out [][2]float64
+	for _, part := range strings.Split(s, ",") {
+		ab := strings.Split(strings.TrimSpace(part), ":")
+		if len(ab) != 2 {
+			return nil, fmt.Errorf("expected alpha:beta, got %q", part)
+		}
+		a, err := strconv.ParseFloat(ab[0], 64)
+		if err != nil {
+			return nil, err
+		}
+		b, err := strconv.ParseFloat(ab[1], 64)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, [2]float64{a, b})
+	}
+	return out, nil
 }
 
 // --- helpers ---
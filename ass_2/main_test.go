@@ -0,0 +1,163 @@
+//go:build !visualisation
+
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// The cached insertions are compared with a brute-force reference at every construction
+// step of every start node, on a few small tables with many ties and on TSPA/TSPB, and
+// the objectives of report.md are reproduced.
+
+// regretReference computes the cheapest insertion of v (total with node cost), its
+// position and the r-regret by sorting the increases of all tour edges
+func regretReference(D [][]int, nodes []Node, tour []int, v int, r int) (bestTot, regret, bestPos int) {
+	m := len(tour)
+	incs := make([]int, m)
+	for i := 0; i < m; i++ {
+		a := tour[i]
+		b := tour[(i+1)%m]
+		incs[i] = D[a][v] + D[v][b] - D[a][b]
+		if i == 0 || incs[i] < incs[bestPos-1] {
+			bestPos = i + 1
+		}
+	}
+	bestTot = incs[bestPos-1] + nodes[v].Cost
+	sort.Ints(incs)
+	for j := 1; j < r && j < m; j++ {
+		regret += incs[j] - incs[0]
+	}
+	return
+}
+
+// checkRegret replays the r-regret construction from the first `starts` start nodes
+func checkRegret(D [][]int, nodes []Node, r int, starts int) error {
+	n := len(nodes)
+	k := (n + 1) / 2
+	for start := 0; start < starts && start < n; start++ {
+		selected := make([]bool, n)
+		cache := newInsertionCache(D, nodes, initialPair(D, nodes, start, selected), selected, r)
+		for len(cache.tour) < k {
+			type cand struct {
+				node, bestTot, regret, bestPos int
+			}
+			var cands []cand
+			for v := 0; v < n; v++ {
+				if selected[v] {
+					continue
+				}
+				bt, reg, pos := regretReference(D, nodes, cache.tour, v, r)
+				if cache.bestTot(v) != bt || cache.regret(v) != reg || cache.bestPos(v) != pos {
+					return fmt.Errorf("start %d, tour size %d, node %d: cache (best %d, regret %d, pos %d), reference (best %d, regret %d, pos %d)",
+						start, len(cache.tour), v, cache.bestTot(v), cache.regret(v), cache.bestPos(v), bt, reg, pos)
+				}
+				cands = append(cands, cand{v, bt, reg, pos})
+			}
+			ch := firstAfterSort(cands, func(a, b cand) bool {
+				if a.regret != b.regret {
+					return a.regret > b.regret
+				}
+				return a.bestTot < b.bestTot
+			})
+			cache.insert(ch.node, ch.bestPos)
+		}
+		got := greedyRegret(D, nodes, k, start, r, false).Tour
+		if intSliceToString(got) != intSliceToString(cache.tour) {
+			return fmt.Errorf("start %d: greedyRegret tour differs from the reference construction", start)
+		}
+	}
+	return nil
+}
+
+// tableNodes builds nodes from x, y, cost triples
+func tableNodes(xyc ...int) []Node {
+	var nodes []Node
+	for i := 0; i+2 < len(xyc); i += 3 {
+		nodes = append(nodes, Node{X: xyc[i], Y: xyc[i+1], Cost: xyc[i+2], Index: len(nodes)})
+	}
+	return nodes
+}
+
+func randomNodes(n int, seed int64) []Node {
+	rnd := rand.New(rand.NewSource(seed))
+	var nodes []Node
+	for i := 0; i < n; i++ {
+		nodes = append(nodes, Node{X: rnd.Intn(50), Y: rnd.Intn(50), Cost: rnd.Intn(30), Index: i})
+	}
+	return nodes
+}
+
+// regretCases are small tables where many insertions tie
+var regretCases = []struct {
+	name  string
+	nodes []Node
+}{
+	{"triangle", tableNodes(0, 0, 0, 10, 0, 0, 0, 10, 0)},
+	{"square with centre", tableNodes(0, 0, 1, 10, 0, 1, 10, 10, 1, 0, 10, 1, 5, 5, 1, 5, 0, 1, 0, 5, 1)},
+	{"collinear", tableNodes(0, 0, 0, 1, 0, 0, 2, 0, 0, 3, 0, 0, 4, 0, 0, 5, 0, 0, 6, 0, 0, 7, 0, 0, 8, 0, 0)},
+	{"duplicate points", tableNodes(0, 0, 5, 0, 0, 5, 3, 4, 1, 3, 4, 1, 6, 8, 2, 6, 8, 2, 0, 0, 5)},
+	{"grid", tableNodes(0, 0, 0, 0, 1, 0, 0, 2, 0, 1, 0, 0, 1, 1, 0, 1, 2, 0, 2, 0, 0, 2, 1, 0, 2, 2, 0, 3, 0, 0, 3, 1, 0, 3, 2, 0)},
+	{"random 41", randomNodes(41, 1)},
+}
+
+func TestRegretCacheTables(t *testing.T) {
+	for _, tc := range regretCases {
+		D := computeDistanceMatrix(tc.nodes)
+		for r := 1; r <= 4; r++ {
+			if err := checkRegret(D, tc.nodes, r, len(tc.nodes)); err != nil {
+				t.Errorf("%s, k=%d: %v", tc.name, r, err)
+			}
+		}
+	}
+}
+
+func TestRegretCacheInstances(t *testing.T) {
+	for _, path := range []string{"../TSPA.csv", "../TSPB.csv"} {
+		nodes, err := readNodesCSV(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		D := computeDistanceMatrix(nodes)
+		for r := 2; r <= 3; r++ {
+			if err := checkRegret(D, nodes, r, 20); err != nil {
+				t.Errorf("%s, k=%d: %v", path, r, err)
+			}
+		}
+	}
+}
+
+// TestReportFigures reproduces the best, worst and average objectives over all start
+// nodes listed in report.md; averages are compared as printed (%.2f)
+func TestReportFigures(t *testing.T) {
+	for _, tc := range []struct {
+		path, method string
+		best, worst  int
+		avg          string
+	}{
+		{"../TSPA.csv", "regret", 105852, 123428, "115474.93"},
+		{"../TSPA.csv", "weighted", 71108, 73438, "72130.85"},
+		{"../TSPB.csv", "regret", 66505, 77072, "72454.77"},
+		{"../TSPB.csv", "weighted", 47144, 55700, "50918.82"},
+	} {
+		nodes, err := readNodesCSV(tc.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		D := computeDistanceMatrix(nodes)
+		k := (len(nodes) + 1) / 2
+		best, worst, avg := runStarts(len(nodes), func(start int) Solution {
+			if tc.method == "regret" {
+				return greedyRegret(D, nodes, k, start, 2, false)
+			}
+			return greedyWeighted(D, nodes, k, start, 2, 1, 1, false)
+		})
+		if got := fmt.Sprintf("%.2f", avg); best.Obj != tc.best || worst != tc.worst || got != tc.avg {
+			t.Errorf("%s %s: best/worst/average %d/%d/%s, report.md has %d/%d/%s",
+				tc.path, tc.method, best.Obj, worst, got, tc.best, tc.worst, tc.avg)
+		}
+	}
+}
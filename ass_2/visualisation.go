@@ -1,3 +1,6 @@
+//go:build visualisation
+
+// The raylib viewer of the results: go run -tags visualisation .
 package main
 
 import (
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ANT COLONY OPTIMISATION (MAX-MIN Ant System)
+//
+// Pheromone lives on edges (tauEdge[i][j]) and on node selection (tauNode[j]).
+// An ant starts at a random node and repeatedly moves to an unvisited node j with
+// probability proportional to (tauEdge[i][j]*tauNode[j])^Alpha * eta[i][j]^Beta,
+// where eta[i][j] = 1/(dist(i,j)+cost(j)), until the cycle has K nodes.
+// The iteration-best ant is improved by steepest local search with 2-opt and then,
+// together with the global best, deposits pheromone. Trails are kept in [tauMin, tauMax].
+
+// ACOOptions configures AntColony
+type ACOOptions struct {
+	Ants       int
+	Alpha      float64 // pheromone exponent
+	Beta       float64 // visibility exponent
+	Rho        float64 // evaporation rate in (0, 1]
+	Budget     time.Duration
+	Iters      int
+	TraceEvery int // record a trace point every TraceEvery iterations (0 = never)
+}
+
+// every acoGlobalBestEvery-th iteration the global best deposits instead of the iteration best
+const acoGlobalBestEvery = 10
+
+// ACOMethod wraps AntColony as a runnable method
+func ACOMethod(opts ACOOptions) Method {
+	return Method{
+		Name:      fmt.Sprintf("aco_ants:%d_alpha:%g_beta:%g_rho:%g", opts.Ants, opts.Alpha, opts.Beta, opts.Rho),
+		StartType: "random",
+		Improve: func(inst *Instance, tour []int, inSel []bool, rnd *rand.Rand) RunResult {
+			return AntColony(inst, tour, inSel, opts, rnd)
+		},
+	}
+}
+
+func AntColony(inst *Instance, tour []int, inSel []bool, opts ACOOptions, rnd *rand.Rand) RunResult {
+	stop := newBudget(opts.Budget, opts.Iters)
+	res := RunResult{}
+	N, K := inst.N, inst.K
+
+	// the starting solution only serves as the first global best
+	best := append([]int{}, tour...)
+	bestObj := Objective(inst, best)
+
+	// visibility^beta
+	etaB := make([][]float64, N)
+	for i := range etaB {
+		etaB[i] = make([]float64, N)
+		for j := range etaB[i] {
+			if i != j {
+				etaB[i][j] = math.Pow(1/float64(inst.Dist[i][j]+inst.Nodes[j].Cost+1), opts.Beta)
+			}
+		}
+	}
+
+	tauMax := 1 / (opts.Rho * float64(bestObj))
+	tauMin := tauMax / (2 * float64(N))
+	tauEdge := make([][]float64, N)
+	for i := range tauEdge {
+		tauEdge[i] = make([]float64, N)
+		for j := range tauEdge[i] {
+			tauEdge[i][j] = tauMax
+		}
+	}
+	tauNode := make([]float64, N)
+	for j := range tauNode {
+		tauNode[j] = tauMax
+	}
+
+	weights := make([]float64, N)
+	construct := func() []int {
+		visited := make([]bool, N)
+		cur := rnd.Intn(N)
+		visited[cur] = true
+		ant := []int{cur}
+		for len(ant) < K {
+			total := 0.0
+			for j := 0; j < N; j++ {
+				weights[j] = 0
+				if !visited[j] {
+					weights[j] = math.Pow(tauEdge[cur][j]*tauNode[j], opts.Alpha) * etaB[cur][j]
+					total += weights[j]
+				}
+			}
+			next := -1
+			r := rnd.Float64() * total
+			for j := 0; j < N; j++ {
+				if visited[j] {
+					continue
+				}
+				next = j
+				r -= weights[j]
+				if r < 0 {
+					break
+				}
+			}
+			visited[next] = true
+			ant = append(ant, next)
+			cur = next
+		}
+		return ant
+	}
+
+	for iter := 0; stop.left(res.Iters); iter++ {
+		res.Iters++
+		var iterBest []int
+		iterBestObj := math.MaxInt
+		for a := 0; a < opts.Ants; a++ {
+			ant := construct()
+			if obj := Objective(inst, ant); obj < iterBestObj {
+				iterBest, iterBestObj = ant, obj
+			}
+		}
+		improved, _, evals, imps := RunLocalSearch(inst, iterBest, selectionOf(N, iterBest), "steepest", "edges", rnd)
+		res.Evals += evals
+		res.Improvements += imps
+		res.Restarts++
+		iterBest, iterBestObj = improved, Objective(inst, improved)
+		if iterBestObj < bestObj {
+			best, bestObj = append(best[:0], iterBest...), iterBestObj
+			tauMax = 1 / (opts.Rho * float64(bestObj))
+			tauMin = tauMax / (2 * float64(N))
+		}
+
+		deposit, depositObj := iterBest, iterBestObj
+		if iter%acoGlobalBestEvery == acoGlobalBestEvery-1 {
+			deposit, depositObj = best, bestObj
+		}
+
+		// evaporation and deposit, clamped to [tauMin, tauMax]
+		for i := 0; i < N; i++ {
+			for j := 0; j < N; j++ {
+				tauEdge[i][j] *= 1 - opts.Rho
+			}
+			tauNode[i] *= 1 - opts.Rho
+		}
+		amount := 1 / float64(depositObj)
+		for i, v := range deposit {
+			w := deposit[(i+1)%len(deposit)]
+			tauEdge[v][w] += amount
+			tauEdge[w][v] += amount
+			tauNode[v] += amount
+		}
+		for i := 0; i < N; i++ {
+			for j := 0; j < N; j++ {
+				tauEdge[i][j] = math.Min(math.Max(tauEdge[i][j], tauMin), tauMax)
+			}
+			tauNode[i] = math.Min(math.Max(tauNode[i], tauMin), tauMax)
+		}
+
+		if opts.TraceEvery > 0 && iter%opts.TraceEvery == 0 {
+			res.Trace = append(res.Trace, TracePoint{Iter: iter, Objective: iterBestObj, Best: bestObj, Value: branchingFactor(tauEdge, tauMin, tauMax)})
+		}
+	}
+	res.Tour = best
+	return res
+}
+
+// branchingFactor is the average number of edges per node whose pheromone exceeds
+// tauMin + 0.05*(tauMax-tauMin); it drops towards 2 as the colony converges
+func branchingFactor(tau [][]float64, tauMin float64, tauMax float64) float64 {
+	threshold := tauMin + 0.05*(tauMax-tauMin)
+	count := 0
+	for i := range tau {
+		for j := range tau[i] {
+			if i != j && tau[i][j] > threshold {
+				count++
+			}
+		}
+	}
+	return float64(count) / float64(len(tau))
+}
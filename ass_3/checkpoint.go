@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CHECKPOINTING
+//
+// The results CSV is the checkpoint: runMethods flushes a row as soon as its job is
+// collected, after the job's trace rows, so every complete row is a finished (method,
+// run) job. With -resume the complete rows of an existing -out are kept (a row cut off
+// by the crash is dropped), trace rows of jobs without a result row are dropped from
+// -trace, only the missing jobs run and their rows are appended. The kept rows must match
+// the resumed run: their options fingerprint (see REPRODUCIBILITY) must be the one of the
+// current flags, so results of two configurations are never mixed in one file, and their
+// run seeds must match the -seed.
+
+var (
+	resultHeader = []string{"method", "run", "objective", "tour_length", "selected_costs", "evaluations", "improvements", "final_selected", "seed", "duration_ms", "ls_restarts", "gap_to_bound", "iterations", "options"}
+	traceHeader  = []string{"method", "run", "iter", "objective", "best", "value", "label"}
+)
+
+// openCSV creates path with the header or, with resume and an existing file, rewrites it
+// with the complete rows accepted by keep; the file is returned open for appending
+func openCSV(path string, header []string, resume bool, keep func(row []string) (bool, error)) (*os.File, error) {
+	data, err := os.ReadFile(path)
+	if !resume || errors.Is(err, os.ErrNotExist) || len(data) == 0 {
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		w := csv.NewWriter(f)
+		w.Write(header)
+		w.Flush()
+		if err := w.Error(); err != nil {
+			f.Close()
+			return nil, err
+		}
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// only lines ended by a newline were written completely
+	data = data[:bytes.LastIndexByte(data, '\n')+1]
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = len(header)
+	got, err := r.Read()
+	if err != nil || strings.Join(got, ",") != strings.Join(header, ",") {
+		return nil, fmt.Errorf("%s: not a checkpoint of this program (header %v)", path, got)
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(header)
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		ok, err := keep(row)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		if ok {
+			w.Write(row)
+		}
+	}
+	w.Flush()
+
+	// replace the file in one step, so a second crash cannot lose the kept rows
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+}
+
+// completedJobs returns a keep function for openCSV that marks the jobs (methodIndex*runs
+// + run) of the results rows in done
+func completedJobs(methods []Method, runs int, seed int64, done []bool) func(row []string) (bool, error) {
+	index := make(map[string]int, len(methods))
+	for i, m := range methods {
+		index[m.Name] = i
+	}
+	return func(row []string) (bool, error) {
+		mi, ok := index[row[0]]
+		if !ok {
+			return false, fmt.Errorf("method %q is not built by these flags", row[0])
+		}
+		run, err := strconv.Atoi(row[1])
+		if err != nil || run < 0 || run >= runs {
+			return false, fmt.Errorf("%s: run %q outside 0..%d", row[0], row[1], runs-1)
+		}
+		if row[13] != methods[mi].Options {
+			return false, fmt.Errorf("%s run %d was made with other options (fingerprint %s, these flags give %s)", row[0], run, row[13], methods[mi].Options)
+		}
+		if row[8] != strconv.FormatInt(RunSeed(seed, methods[mi], run), 10) {
+			return false, fmt.Errorf("%s run %d was made with another -seed", row[0], run)
+		}
+		if done[mi*runs+run] {
+			return false, nil
+		}
+		done[mi*runs+run] = true
+		return true, nil
+	}
+}
+
+// tracedJobs keeps the trace rows of the jobs in done
+func tracedJobs(methods []Method, runs int, done []bool) func(row []string) (bool, error) {
+	index := make(map[string]int, len(methods))
+	for i, m := range methods {
+		index[m.Name] = i
+	}
+	return func(row []string) (bool, error) {
+		mi, ok := index[row[0]]
+		run, err := strconv.Atoi(row[1])
+		return ok && err == nil && run >= 0 && run < runs && done[mi*runs+run], nil
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// TestResumeAfterCrash cuts a results CSV off in the middle of a row, resumes it and
+// checks that the complete rows are kept as they were, the cut run is redone and every
+// run appears once with the result of an uninterrupted run
+func TestResumeAfterCrash(t *testing.T) {
+	const (
+		runs = 6
+		kept = 3
+		seed = 11
+	)
+	inst := SubInstance(readTestInstance(t, "../TSPA.csv"), 40)
+	opts, err := DefaultOptions().With(map[string]string{"algo": "ils", "perturb": "replace", "iters": "5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	methods, err := BuildMethods(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	full := filepath.Join(dir, "full.csv")
+	if err := runMethods(inst, methods, runs, seed, 1, full, "", 0, false); err != nil {
+		t.Fatal(err)
+	}
+	want := readResults(t, full)
+
+	// the header, kept complete rows and half of the next one
+	data, err := os.ReadFile(full)
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := 0
+	for i := 0; i <= kept; i++ {
+		end += bytes.IndexByte(data[end:], '\n') + 1
+	}
+	next := bytes.IndexByte(data[end:], '\n')
+	out := filepath.Join(dir, "out.csv")
+	if err := os.WriteFile(out, data[:end+next/2], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runMethods(inst, methods, runs, seed, 1, out, "", 0, true); err != nil {
+		t.Fatal(err)
+	}
+
+	got := readResults(t, out)
+	if len(got) != runs {
+		t.Fatalf("%d rows after resume, want %d", len(got), runs)
+	}
+	seen := map[string]bool{}
+	for i, row := range got {
+		if seen[row["run"]] {
+			t.Errorf("run %s appears twice", row["run"])
+		}
+		seen[row["run"]] = true
+		run, _ := strconv.Atoi(row["run"])
+		for _, col := range []string{"method", "objective", "final_selected", "seed", "iterations", "options"} {
+			if row[col] != want[run][col] {
+				t.Errorf("run %d: %s %q, the uninterrupted run has %q", run, col, row[col], want[run][col])
+			}
+		}
+		// kept rows are not rerun: even their durations are unchanged
+		if i < kept && !slices.Equal(resultValues(row), resultValues(want[i])) {
+			t.Errorf("row %d changed on resume", i)
+		}
+	}
+
+	// resuming with other options or another seed must fail instead of mixing results
+	other, err := opts.With(map[string]string{"strength": "3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherMethods, err := BuildMethods(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if otherMethods[0].Name != methods[0].Name {
+		t.Fatalf("-strength changes the method name, pick an option it leaves out")
+	}
+	if err := runMethods(inst, otherMethods, runs, seed, 1, out, "", 0, true); err == nil {
+		t.Errorf("resume with another -strength accepted")
+	}
+	if err := runMethods(inst, methods, runs, seed+1, 1, out, "", 0, true); err == nil {
+		t.Errorf("resume with another -seed accepted")
+	}
+}
+
+// resultValues returns the columns of a results row in header order
+func resultValues(row map[string]string) []string {
+	values := make([]string, len(resultHeader))
+	for i, name := range resultHeader {
+		values[i] = row[name]
+	}
+	return values
+}
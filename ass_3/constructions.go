@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// CONSTRUCTION HEURISTICS ported from ass_1/ass_1.py
+//
+// They share the signature of GreedyRegretStart. Ties are broken like the Python
+// versions (first candidate in increasing node index, then increasing position),
+// so the objectives match nn_end, nn_insert_anywhere and greedy_cycle (constructions_test.go
+// holds objectives recorded from the Python code).
+
+// Construction builds a starting solution from a start node
+type Construction func(inst *Instance, startNode int) ([]int, []bool)
+
+// Constructions lists the deterministic constructions by start type name
+var Constructions = map[string]Construction{
+	"greedy":       GreedyRegretStart,
+	"regret":       RegretOnlyStart,
+	"nn_end":       NNEndStart,
+	"nn_anywhere":  NNAnywhereStart,
+	"greedy_cycle": GreedyCycleStart,
+	"hilbert":      HilbertStart,
+	"hull":         HullInsertionStart,
+
+	// selection first, routing second (selectroute.go)
+	"sr_score_nn":     selectRoute(selectByScore, routeNN2Opt),
+	"sr_score_mst":    selectRoute(selectByScore, routeChristofides),
+	"sr_medoids_nn":   selectRoute(selectByMedoids, routeNN2Opt),
+	"sr_medoids_mst":  selectRoute(selectByMedoids, routeChristofides),
+	"sr_knapsack_nn":  selectRoute(selectByKnapsack, routeNN2Opt),
+	"sr_knapsack_mst": selectRoute(selectByKnapsack, routeChristofides),
+}
+
+// Nearest neighbour appending at the end of the path (nn_end)
+func NNEndStart(inst *Instance, startNode int) ([]int, []bool) {
+	D := inst.Dist
+	selected := make([]bool, inst.N)
+	selected[startNode] = true
+	path := []int{startNode}
+	for len(path) < inst.K {
+		last := path[len(path)-1]
+		bestJ, bestDelta := -1, math.MaxInt
+		for j := 0; j < inst.N; j++ {
+			if selected[j] {
+				continue
+			}
+			// closing edge last -> start is replaced by last -> j -> start
+			delta := D[last][j] + D[j][startNode] - D[last][startNode] + inst.Nodes[j].Cost
+			if delta < bestDelta {
+				bestJ, bestDelta = j, delta
+			}
+		}
+		selected[bestJ] = true
+		path = append(path, bestJ)
+	}
+	return path, selected
+}
+
+// Nearest neighbour inserting anywhere in the cycle (nn_insert_anywhere)
+func NNAnywhereStart(inst *Instance, startNode int) ([]int, []bool) {
+	D := inst.Dist
+	selected := make([]bool, inst.N)
+	selected[startNode] = true
+	// second node: cheapest round trip from the start node
+	bestJ, bestVal := -1, math.MaxInt
+	for j := 0; j < inst.N; j++ {
+		if j == startNode {
+			continue
+		}
+		if val := D[startNode][j] + D[j][startNode] + inst.Nodes[j].Cost; val < bestVal {
+			bestJ, bestVal = j, val
+		}
+	}
+	selected[bestJ] = true
+	return cheapestInsertion(inst, []int{startNode, bestJ}, selected), selected
+}
+
+// Greedy cycle (greedy_cycle): starts from the cheapest pair of nodes, so startNode is ignored
+func GreedyCycleStart(inst *Instance, startNode int) ([]int, []bool) {
+	bestA, bestB, bestVal := -1, -1, math.MaxInt
+	for a := 0; a < inst.N; a++ {
+		for b := a + 1; b < inst.N; b++ {
+			if val := inst.Dist[a][b] + inst.Nodes[a].Cost + inst.Nodes[b].Cost; val < bestVal {
+				bestA, bestB, bestVal = a, b, val
+			}
+		}
+	}
+	selected := make([]bool, inst.N)
+	selected[bestA], selected[bestB] = true, true
+	return cheapestInsertion(inst, []int{bestA, bestB}, selected), selected
+}
+
+// cheapestInsertion inserts the node with the cheapest insertion (edge increase + cost) until K nodes
+func cheapestInsertion(inst *Instance, path []int, selected []bool) []int {
+	D := inst.Dist
+	for len(path) < inst.K {
+		bestDelta, bestJ, bestPos := math.MaxInt, -1, -1
+		for j := 0; j < inst.N; j++ {
+			if selected[j] {
+				continue
+			}
+			for i := 0; i < len(path); i++ {
+				i2 := (i + 1) % len(path)
+				delta := D[path[i]][j] + D[j][path[i2]] - D[path[i]][path[i2]] + inst.Nodes[j].Cost
+				if delta < bestDelta {
+					bestDelta, bestJ, bestPos = delta, j, i2
+				}
+			}
+		}
+		selected[bestJ] = true
+		path = insertAt(path, bestPos, bestJ)
+	}
+	return path
+}
+
+// ConstructionMethod evaluates a start type without any improvement
+func ConstructionMethod(startType string) Method {
+	return Method{
+		Name:      fmt.Sprintf("construct_start:%s", startType),
+		StartType: startType,
+		Improve: func(inst *Instance, tour []int, inSel []bool, rnd *rand.Rand) RunResult {
+			return RunResult{Tour: tour}
+		},
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Objectives of nn_end, nn_insert_anywhere and greedy_cycle from ass_1/ass_1.py on the
+// instances of the repository root, for fixed start nodes.
+var pythonObjectives = []struct {
+	path        string
+	start       int
+	nnEnd       int
+	nnAnywhere  int
+	greedyCycle int // start node is ignored
+}{
+	{"../TSPA.csv", 0, 102228, 71488, 72639},
+	{"../TSPA.csv", 17, 115691, 73649, 72639},
+	{"../TSPA.csv", 99, 101841, 73866, 72639},
+	{"../TSPA.csv", 150, 98022, 73729, 72639},
+	{"../TSPA.csv", 199, 102291, 72629, 72639},
+	{"../TSPB.csv", 0, 68291, 50243, 50243},
+	{"../TSPB.csv", 17, 75450, 57324, 50243},
+	{"../TSPB.csv", 99, 69950, 51149, 50243},
+	{"../TSPB.csv", 150, 74578, 51642, 50243},
+	{"../TSPB.csv", 199, 70042, 52247, 50243},
+}
+
+func TestConstructionsMatchPython(t *testing.T) {
+	insts := map[string]*Instance{}
+	for _, tc := range pythonObjectives {
+		inst := insts[tc.path]
+		if inst == nil {
+			inst = readTestInstance(t, tc.path)
+			insts[tc.path] = inst
+		}
+		for _, c := range []struct {
+			name string
+			want int
+		}{{"nn_end", tc.nnEnd}, {"nn_anywhere", tc.nnAnywhere}, {"greedy_cycle", tc.greedyCycle}} {
+			tour, inSel := Constructions[c.name](inst, tc.start)
+			if err := ValidateSolution(inst, tour, inSel); err != nil {
+				t.Errorf("%s %s start %d: %v", tc.path, c.name, tc.start, err)
+			}
+			if got := Objective(inst, tour); got != c.want {
+				t.Errorf("%s %s start %d: objective %d, Python gives %d", tc.path, c.name, tc.start, got, c.want)
+			}
+		}
+	}
+}
+
+// degenerateInstances are small point sets where the hull has fewer than three points
+// or many points lie on it
+var degenerateInstances = map[string]string{
+	"collinear":  "0;0;1\n1;0;1\n2;0;1\n3;0;1\n4;0;1\n5;0;1\n6;0;1",
+	"duplicates": "5;5;1\n5;5;2\n5;5;3\n5;5;4\n5;5;5",
+	"square":     "0;0;1\n0;4;1\n4;0;1\n4;4;1\n0;2;1\n2;0;1\n4;2;1\n2;4;1\n2;2;1",
+}
+
+// TestConstructionsValid checks that the constructions without a Python counterpart give
+// valid K-node cycles from several start nodes, on TSPA/TSPB, a small odd cut of TSPA and
+// the degenerate instances
+func TestConstructionsValid(t *testing.T) {
+	insts := map[string]*Instance{}
+	for _, path := range testInstances {
+		insts[path] = readTestInstance(t, path)
+	}
+	insts["../TSPA.csv -sub 31"] = SubInstance(insts[testInstances[0]], 31)
+	dir := t.TempDir()
+	for name, rows := range degenerateInstances {
+		path := filepath.Join(dir, name+".csv")
+		if err := os.WriteFile(path, []byte("x;y;cost\n"+rows+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		insts[name] = readTestInstance(t, path)
+	}
+	for _, name := range []string{
+		"hilbert", "hull",
+		"sr_score_nn", "sr_score_mst",
+		"sr_medoids_nn", "sr_medoids_mst",
+		"sr_knapsack_nn", "sr_knapsack_mst",
+	} {
+		for instName, inst := range insts {
+			for _, start := range []int{0, inst.N / 2, inst.N - 1} {
+				tour, inSel := Constructions[name](inst, start)
+				if err := ValidateSolution(inst, tour, inSel); err != nil {
+					t.Errorf("%s: %s start %d: %v", instName, name, start, err)
+				} else if (name == "hilbert" || name == "hull") && tour[0] != start {
+					t.Errorf("%s: %s start %d: tour begins with %d", instName, name, start, tour[0])
+				}
+			}
+		}
+	}
+}
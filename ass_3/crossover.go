@@ -0,0 +1,404 @@
+package main
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// RECOMBINATION OPERATORS
+//
+// Every operator takes two parent tours (each a K-node cycle) and returns a child
+// tour with K nodes together with its selection vector.
+
+// Recombination is a crossover operator for the selection-TSP
+type Recombination func(inst *Instance, p1 []int, p2 []int, rnd *rand.Rand) ([]int, []bool)
+
+// Recombinations lists the available operators by name
+var Recombinations = map[string]Recombination{
+	"regret":      RecombineCommonRegret,
+	"random-fill": RecombineCommonRandom,
+	"erx":         RecombineERX,
+	"ox":          RecombineOX,
+	"gpx":         RecombineGPX,
+	"pr":          RecombinePathRelink,
+}
+
+// RecombinationNames returns the operator names in a fixed order
+func RecombinationNames() []string {
+	names := make([]string, 0, len(Recombinations))
+	for name := range Recombinations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// selectionOf builds the selection vector of a tour
+func selectionOf(n int, tour []int) []bool {
+	inSel := make([]bool, n)
+	for _, v := range tour {
+		inSel[v] = true
+	}
+	return inSel
+}
+
+// tourAdjacency returns next/prev of every node of the tour (-1 for unselected nodes)
+func tourAdjacency(n int, tour []int) (next, prev []int) {
+	next = make([]int, n)
+	prev = make([]int, n)
+	for i := range next {
+		next[i], prev[i] = -1, -1
+	}
+	K := len(tour)
+	for i, v := range tour {
+		next[v] = tour[(i+1)%K]
+		prev[v] = tour[mod(i-1, K)]
+	}
+	return next, prev
+}
+
+// commonSubpaths splits p1 into maximal subpaths whose edges are also present in p2.
+// Nodes of p1 not selected in p2 are dropped.
+func commonSubpaths(n int, p1 []int, p2 []int) [][]int {
+	next2, prev2 := tourAdjacency(n, p2)
+	inP2 := func(v int) bool { return next2[v] >= 0 }
+	common := func(a, b int) bool { return next2[a] == b || prev2[a] == b }
+
+	K := len(p1)
+	// start walking right after an edge that is not common so no subpath wraps around
+	first := -1
+	for i := 0; i < K; i++ {
+		if !common(p1[mod(i-1, K)], p1[i]) {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		// identical cycles
+		return [][]int{append([]int{}, p1...)}
+	}
+
+	var paths [][]int
+	var curPath []int
+	for k := 0; k < K; k++ {
+		v := p1[(first+k)%K]
+		if !inP2(v) {
+			if len(curPath) > 0 {
+				paths = append(paths, curPath)
+				curPath = nil
+			}
+			continue
+		}
+		if len(curPath) > 0 && !common(curPath[len(curPath)-1], v) {
+			paths = append(paths, curPath)
+			curPath = nil
+		}
+		curPath = append(curPath, v)
+	}
+	if len(curPath) > 0 {
+		paths = append(paths, curPath)
+	}
+	return paths
+}
+
+// RecombineCommonRegret keeps the nodes and edges common to both parents (in the order of p1)
+// and completes the child with weighted 2-regret insertion.
+func RecombineCommonRegret(inst *Instance, p1 []int, p2 []int, rnd *rand.Rand) ([]int, []bool) {
+	child := make([]int, 0, inst.K)
+	for _, path := range commonSubpaths(inst.N, p1, p2) {
+		child = append(child, path...)
+	}
+	inSel := selectionOf(inst.N, child)
+	if len(child) < 2 {
+		// nothing in common: start from a random edge of p1
+		i := rnd.Intn(len(p1))
+		child = []int{p1[i], p1[(i+1)%len(p1)]}
+		inSel = make([]bool, inst.N)
+		inSel[child[0]], inSel[child[1]] = true, true
+	}
+	child = RegretRepair(inst, child, inSel)
+	return child, inSel
+}
+
+// RecombineCommonRandom keeps the common subpaths (in random order and orientation)
+// and fills the child with random nodes inserted at random positions.
+func RecombineCommonRandom(inst *Instance, p1 []int, p2 []int, rnd *rand.Rand) ([]int, []bool) {
+	paths := commonSubpaths(inst.N, p1, p2)
+	rnd.Shuffle(len(paths), func(i, j int) { paths[i], paths[j] = paths[j], paths[i] })
+	child := make([]int, 0, inst.K)
+	for _, path := range paths {
+		if rnd.Intn(2) == 0 {
+			for a, b := 0, len(path)-1; a < b; a, b = a+1, b-1 {
+				path[a], path[b] = path[b], path[a]
+			}
+		}
+		child = append(child, path...)
+	}
+	inSel := selectionOf(inst.N, child)
+	unselected := make([]int, 0, inst.N-len(child))
+	for v := 0; v < inst.N; v++ {
+		if !inSel[v] {
+			unselected = append(unselected, v)
+		}
+	}
+	rnd.Shuffle(len(unselected), func(i, j int) { unselected[i], unselected[j] = unselected[j], unselected[i] })
+	for _, u := range unselected[:inst.K-len(child)] {
+		child = insertAt(child, rnd.Intn(len(child)+1), u)
+		inSel[u] = true
+	}
+	return child, inSel
+}
+
+// RecombineERX is edge recombination over the union of both parents' edges.
+// The walk always moves to the neighbour with the fewest remaining neighbours and
+// jumps to a random unused parent node (common nodes first) when it gets stuck.
+func RecombineERX(inst *Instance, p1 []int, p2 []int, rnd *rand.Rand) ([]int, []bool) {
+	n := inst.N
+	neighbours := make([][]int, n)
+	addEdge := func(a, b int) {
+		for _, x := range neighbours[a] {
+			if x == b {
+				return
+			}
+		}
+		neighbours[a] = append(neighbours[a], b)
+	}
+	for _, p := range [][]int{p1, p2} {
+		K := len(p)
+		for i, v := range p {
+			addEdge(v, p[(i+1)%K])
+			addEdge(v, p[mod(i-1, K)])
+		}
+	}
+	inP1, inP2 := selectionOf(n, p1), selectionOf(n, p2)
+
+	used := make([]bool, n)
+	remaining := func(v int) int {
+		c := 0
+		for _, x := range neighbours[v] {
+			if !used[x] {
+				c++
+			}
+		}
+		return c
+	}
+
+	child := make([]int, 0, inst.K)
+	cur := p1[rnd.Intn(len(p1))]
+	for {
+		used[cur] = true
+		child = append(child, cur)
+		if len(child) == inst.K {
+			break
+		}
+		next, bestRem, ties := -1, 0, 0
+		for _, x := range neighbours[cur] {
+			if used[x] {
+				continue
+			}
+			r := remaining(x)
+			if next < 0 || r < bestRem {
+				next, bestRem, ties = x, r, 1
+			} else if r == bestRem {
+				ties++
+				if rnd.Intn(ties) == 0 {
+					next = x
+				}
+			}
+		}
+		if next < 0 {
+			var pool, fallback []int
+			for v := 0; v < n; v++ {
+				if used[v] {
+					continue
+				}
+				if inP1[v] && inP2[v] {
+					pool = append(pool, v)
+				} else if inP1[v] || inP2[v] {
+					fallback = append(fallback, v)
+				}
+			}
+			if len(pool) == 0 {
+				pool = fallback
+			}
+			next = pool[rnd.Intn(len(pool))]
+		}
+		cur = next
+	}
+	return child, used
+}
+
+// RecombineOX is order crossover adapted to partial selections: a random segment of p1
+// keeps its positions, the other positions are filled with p2's nodes in p2 order
+// (starting after the segment), then with the remaining nodes of p1 if p2 runs out.
+func RecombineOX(inst *Instance, p1 []int, p2 []int, rnd *rand.Rand) ([]int, []bool) {
+	K := inst.K
+	a := rnd.Intn(K)
+	b := a + rnd.Intn(K-a)
+	child := make([]int, K)
+	inSel := make([]bool, inst.N)
+	for i := a; i <= b; i++ {
+		child[i] = p1[i]
+		inSel[p1[i]] = true
+	}
+
+	fill := make([]int, 0, K)
+	for k := 0; k < len(p2) && len(fill) < K-(b-a+1); k++ {
+		v := p2[(b+1+k)%len(p2)]
+		if !inSel[v] {
+			fill = append(fill, v)
+			inSel[v] = true
+		}
+	}
+	for k := 0; k < len(p1) && len(fill) < K-(b-a+1); k++ {
+		v := p1[(b+1+k)%len(p1)]
+		if !inSel[v] {
+			fill = append(fill, v)
+			inSel[v] = true
+		}
+	}
+	for k, v := range fill {
+		child[(b+1+k)%K] = v
+	}
+	return child, inSel
+}
+
+// RecombineGPX is partition crossover adapted to the selection-TSP.
+// The union graph without the common edges splits into components; in every component
+// that both parents visit as one contiguous subpath, the child takes the cheaper parent's
+// subpath (edges + node costs). The child is built along p1 and then trimmed (largest
+// removal gain first) or completed (2-regret insertion) to exactly K nodes.
+func RecombineGPX(inst *Instance, p1 []int, p2 []int, rnd *rand.Rand) ([]int, []bool) {
+	n := inst.N
+	next1, prev1 := tourAdjacency(n, p1)
+	next2, prev2 := tourAdjacency(n, p2)
+	isCommon := func(a, b int) bool {
+		return (next1[a] == b || prev1[a] == b) && (next2[a] == b || prev2[a] == b)
+	}
+
+	// union-find over the non-common edges
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(x int) int {
+		if parent[x] != x {
+			parent[x] = find(parent[x])
+		}
+		return parent[x]
+	}
+	for _, p := range [][]int{p1, p2} {
+		for i, v := range p {
+			w := p[(i+1)%len(p)]
+			if !isCommon(v, w) {
+				parent[find(v)] = find(w)
+			}
+		}
+	}
+
+	// walk p1 from a component boundary so no component wraps around position 0
+	K1 := len(p1)
+	first := -1
+	for i := 0; i < K1; i++ {
+		if find(p1[mod(i-1, K1)]) != find(p1[i]) {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		// a single component: nothing to choose from
+		return RecombineCommonRegret(inst, p1, p2, rnd)
+	}
+	walk := make([]int, K1)
+	for k := range walk {
+		walk[k] = p1[(first+k)%K1]
+	}
+
+	// subpath of a parent inside a component, or nil if the parent enters it more than once
+	segment := func(p []int, comp int) []int {
+		K := len(p)
+		start, count := -1, 0
+		for i, v := range p {
+			if find(v) != comp {
+				continue
+			}
+			count++
+			if find(p[mod(i-1, K)]) != comp {
+				if start >= 0 {
+					return nil
+				}
+				start = i
+			}
+		}
+		if start < 0 {
+			return nil
+		}
+		seg := make([]int, count)
+		for k := range seg {
+			seg[k] = p[(start+k)%K]
+		}
+		return seg
+	}
+	segCost := func(seg []int, x int, y int) int {
+		c := inst.Dist[x][seg[0]] + inst.Dist[seg[len(seg)-1]][y]
+		for i, v := range seg {
+			c += inst.Nodes[v].Cost
+			if i > 0 {
+				c += inst.Dist[seg[i-1]][v]
+			}
+		}
+		return c
+	}
+
+	child := make([]int, 0, 2*inst.K)
+	for i := 0; i < K1; {
+		comp := find(walk[i])
+		j := i
+		for j < K1 && find(walk[j]) == comp {
+			j++
+		}
+		s1 := walk[i:j]
+		choice := s1
+		if s2 := segment(p2, comp); s2 != nil && segment(p1, comp) != nil {
+			x := walk[mod(i-1, K1)]
+			y := walk[j%K1]
+			rev := make([]int, len(s2))
+			for k := range s2 {
+				rev[k] = s2[len(s2)-1-k]
+			}
+			for _, cand := range [][]int{s2, rev} {
+				if segCost(cand, x, y) < segCost(choice, x, y) {
+					choice = cand
+				}
+			}
+		}
+		child = append(child, choice...)
+		i = j
+	}
+
+	inSel := selectionOf(n, child)
+	child = trimToK(inst, child, inSel)
+	child = RegretRepair(inst, child, inSel)
+	return child, inSel
+}
+
+// trimToK removes nodes with the largest removal gain until at most K remain
+func trimToK(inst *Instance, tour []int, inSel []bool) []int {
+	for len(tour) > inst.K {
+		K := len(tour)
+		bestPos, bestGain := 0, 0
+		for pos := 0; pos < K; pos++ {
+			prev := tour[mod(pos-1, K)]
+			v := tour[pos]
+			next := tour[mod(pos+1, K)]
+			g := inst.Dist[prev][v] + inst.Dist[v][next] - inst.Dist[prev][next] + inst.Nodes[v].Cost
+			if pos == 0 || g > bestGain {
+				bestPos, bestGain = pos, g
+			}
+		}
+		inSel[tour[bestPos]] = false
+		tour = append(tour[:bestPos], tour[bestPos+1:]...)
+	}
+	return tour
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+var testInstances = []string{"../TSPA.csv", "../TSPB.csv"}
+
+// readTestInstance reads an instance of the repository or fails the test
+func readTestInstance(t *testing.T, path string) *Instance {
+	t.Helper()
+	inst, err := ReadInstanceCSV(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+	return inst
+}
+
+// TestRecombinationsValid runs every operator on pairs of local optima (the first pair
+// has identical parents) and checks that the children are valid K-node cycles
+func TestRecombinationsValid(t *testing.T) {
+	const pairs = 5
+	for _, path := range testInstances {
+		inst := readTestInstance(t, path)
+		for _, name := range RecombinationNames() {
+			op := Recombinations[name]
+			rnd := rand.New(rand.NewSource(1))
+			for k := 0; k < pairs; k++ {
+				t1, s1 := RandomStart(inst, rnd)
+				p1, _, _, _ := RunLocalSearch(inst, t1, s1, "greedy", "edges", rnd)
+				p2 := p1
+				if k > 0 {
+					t2, s2 := RandomStart(inst, rnd)
+					p2, _, _, _ = RunLocalSearch(inst, t2, s2, "greedy", "edges", rnd)
+				}
+				child, inSel := op(inst, p1, p2, rnd)
+				if err := ValidateSolution(inst, child, inSel); err != nil {
+					t.Errorf("%s: %s pair %d: %v", path, name, k, err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"math/rand"
+	"sort"
+)
+
+// EXACT SOLVER (small instances)
+//
+// Held-Karp dynamic programming over selected subsets. Every cycle is rooted at its
+// lowest-index node s; for a fixed s the states are (subset of nodes > s, last node):
+//   dp[S][v] = min cost of a path s -> ... -> v visiting exactly S (edges + node costs, s included)
+// Subsets are processed in layers of equal size; inside a layer they are enumerated in
+// increasing numeric order (Gosper's hack), which is the colexicographic order, so a
+// subset's rank is simply its position and predecessors are ranked with binomials.
+// The answer is min over |S| = K-1 of dp[S][v] + dist(v, s).
+
+// ExactMaxN is the largest instance the exact solver accepts (memory grows as C(N, N/2))
+const ExactMaxN = 25
+
+// SubInstance returns the instance restricted to its first n nodes, with K = ceil(n/2)
+func SubInstance(inst *Instance, n int) *Instance {
+	sub := &Instance{Nodes: append([]Node{}, inst.Nodes[:n]...), N: n, K: (n + 1) / 2, Dist: make([][]int, n), Weights: inst.Weights}
+	for i := 0; i < n; i++ {
+		sub.Dist[i] = append([]int{}, inst.Dist[i][:n]...)
+	}
+	return sub
+}
+
+// binomial table c[n][k] for n, k <= m
+func binomials(m int) [][]int {
+	c := make([][]int, m+1)
+	for n := range c {
+		c[n] = make([]int, m+1)
+		c[n][0] = 1
+		for k := 1; k <= n; k++ {
+			c[n][k] = c[n-1][k-1] + c[n-1][k]
+		}
+	}
+	return c
+}
+
+// colexRank of a subset given its set bit positions in increasing order
+func colexRank(positions []int, c [][]int) int {
+	r := 0
+	for i, p := range positions {
+		r += c[p][i+1]
+	}
+	return r
+}
+
+// SolveExact returns an optimal tour and its objective
+func SolveExact(inst *Instance) ([]int, int, error) {
+	N, K := inst.N, inst.K
+	if N > ExactMaxN {
+		return nil, 0, fmt.Errorf("exact solver supports N <= %d, got %d", ExactMaxN, N)
+	}
+	if K < 3 {
+		return nil, 0, fmt.Errorf("exact solver needs K >= 3, got %d", K)
+	}
+	c := binomials(N)
+	bestObj := math.MaxInt
+	var bestTour []int
+
+	for s := 0; s+K <= N; s++ {
+		M := N - 1 - s // candidate nodes s+1..N-1 are bits 0..M-1
+		node := func(bit int) int { return s + 1 + bit }
+		// layers[p-1]: dp values of subsets of size p, parent[p-1]: index of the previous last node
+		layers := make([][]int32, K-1)
+		parents := make([][]int8, K-1)
+
+		layers[0] = make([]int32, M)
+		parents[0] = make([]int8, M)
+		for b := 0; b < M; b++ {
+			v := node(b)
+			layers[0][b] = int32(inst.Nodes[s].Cost + inst.Dist[s][v] + inst.Nodes[v].Cost)
+			parents[0][b] = -1
+		}
+
+		positions := make([]int, 0, K)
+		prevPos := make([]int, 0, K)
+		for p := 2; p <= K-1; p++ {
+			cur := make([]int32, c[M][p]*p)
+			par := make([]int8, c[M][p]*p)
+			prev := layers[p-2]
+			rank := 0
+			for mask := uint32(1)<<p - 1; mask < 1<<M; mask = nextSubset(mask) {
+				positions = positions[:0]
+				for m := mask; m != 0; m &= m - 1 {
+					positions = append(positions, bits.TrailingZeros32(m))
+				}
+				for a, vb := range positions {
+					// predecessor subset without v
+					prevPos = append(prevPos[:0], positions[:a]...)
+					prevPos = append(prevPos, positions[a+1:]...)
+					base := colexRank(prevPos, c) * (p - 1)
+					v := node(vb)
+					best, bestW := int32(math.MaxInt32), int8(-1)
+					for b, wb := range prevPos {
+						val := prev[base+b] + int32(inst.Dist[node(wb)][v])
+						if val < best {
+							best, bestW = val, int8(b)
+						}
+					}
+					cur[rank*p+a] = best + int32(inst.Nodes[v].Cost)
+					par[rank*p+a] = bestW
+				}
+				rank++
+			}
+			layers[p-1] = cur
+			parents[p-1] = par
+			if p >= 3 {
+				layers[p-3] = nil // dp values of older layers are no longer needed
+			}
+		}
+
+		// close the cycle
+		p := K - 1
+		last := layers[p-1]
+		rank := 0
+		bestMask, bestA := uint32(0), -1
+		for mask := uint32(1)<<p - 1; mask < 1<<M; mask = nextSubset(mask) {
+			a := 0
+			for m := mask; m != 0; m &= m - 1 {
+				v := node(bits.TrailingZeros32(m))
+				if obj := int(last[rank*p+a]) + inst.Dist[v][s]; obj < bestObj {
+					bestObj, bestMask, bestA = obj, mask, a
+				}
+				a++
+			}
+			rank++
+		}
+		if bestA < 0 {
+			continue
+		}
+
+		// backtrack through the parent pointers
+		tour := make([]int, 0, K)
+		mask, a := bestMask, bestA
+		for size := p; size >= 1; size-- {
+			positions = positions[:0]
+			for m := mask; m != 0; m &= m - 1 {
+				positions = append(positions, bits.TrailingZeros32(m))
+			}
+			r := colexRank(positions, c)
+			tour = append(tour, node(positions[a]))
+			w := int(parents[size-1][r*size+a])
+			mask &^= 1 << positions[a]
+			if w >= 0 {
+				// w indexes the remaining set bits
+				a = w
+			}
+		}
+		tour = append(tour, s)
+		for i, j := 0, len(tour)-1; i < j; i, j = i+1, j-1 {
+			tour[i], tour[j] = tour[j], tour[i]
+		}
+		bestTour = tour
+	}
+	if bestTour == nil {
+		return nil, 0, fmt.Errorf("no feasible cycle found")
+	}
+	return bestTour, bestObj, nil
+}
+
+// nextSubset returns the next larger integer with the same number of set bits (Gosper's hack)
+func nextSubset(x uint32) uint32 {
+	c := x & -x
+	r := x + c
+	return (((r ^ x) >> 2) / c) | r
+}
+
+// ExactGapReport solves the instance exactly and prints the gap of the regret
+// constructions (every start node) and of the local search variants (runs each)
+func ExactGapReport(inst *Instance, runs int, rnd *rand.Rand) error {
+	optTour, opt, err := SolveExact(inst)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Optimum for N=%d, K=%d: %d, tour %v\n", inst.N, inst.K, opt, optTour)
+
+	gaps := map[string][]float64{}
+	var names []string
+	add := func(name string, tour []int) {
+		if _, ok := gaps[name]; !ok {
+			names = append(names, name)
+		}
+		gaps[name] = append(gaps[name], 100*float64(Objective(inst, tour)-opt)/float64(opt))
+	}
+	for start := 0; start < inst.N; start++ {
+		t, _ := RegretOnlyStart(inst, start)
+		add("regret", t)
+		t, _ = GreedyRegretStart(inst, start)
+		add("weighted", t)
+	}
+	for _, m := range LocalSearchMethods([]string{"random", "greedy"}) {
+		for run := 0; run < runs; run++ {
+			t, s := StartSolution(inst, m.StartType, run, rnd)
+			add(m.Name, m.Improve(inst, t, s, rnd).Tour)
+		}
+	}
+
+	fmt.Printf("%-36s %10s %10s %10s\n", "method", "best gap%", "avg gap%", "worst gap%")
+	for _, name := range names {
+		g := gaps[name]
+		sort.Float64s(g)
+		sum := 0.0
+		for _, v := range g {
+			sum += v
+		}
+		fmt.Printf("%-36s %10.2f %10.2f %10.2f\n", name, g[0], sum/float64(len(g)), g[len(g)-1])
+	}
+	return nil
+}
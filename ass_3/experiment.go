@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DECLARATIVE EXPERIMENTS
+//
+// `experiment run config.json` runs instances x methods x parameter grids x budgets with
+// the given runs and seed. Methods are described by the options of the command-line flags
+// (see METHOD OPTIONS), so every -algo and every option is available: each method entry
+// starts from the defaults, then the common "flags" and the entry's "flags" are applied
+// and every combination of the entry's "grid" values is run. Example:
+//
+//	{
+//	  "out": "results/ils_vs_lns",
+//	  "instances": ["../TSPA.csv", "../TSPB.csv"],
+//	  "runs": 20,
+//	  "seed": 42,
+//	  "budgets": ["1s", "2s"],
+//	  "flags": {"workers": "4", "lbiters": "0"},
+//	  "methods": [
+//	    {"algo": "ls", "flags": {"starts": "random,greedy"}},
+//	    {"algo": "ils", "grid": {"perturb": ["double-bridge", "replace"]}},
+//	    {"algo": "lns", "flags": {"destroy": "adaptive"}}
+//	  ]
+//	}
+//
+// The results directory gets one results CSV (and trace CSV with "trace": true) per
+// instance, budget and method configuration, a copy of the config and metadata.json
+// describing every file. Algorithms without a time budget run once per instance.
+//
+// With -resume an interrupted experiment continues in its results directory: files listed
+// in metadata.json are complete and skipped, the file that was running is resumed from its
+// checkpoint (see CHECKPOINTING) and the missing files are run.
+
+// ExperimentConfig is the content of an experiment file
+type ExperimentConfig struct {
+	Out       string             `json:"out"`       // results directory
+	Instances []string           `json:"instances"` // instance CSV paths, relative to the config file
+	Runs      int                `json:"runs"`
+	Seed      int64              `json:"seed"`    // 0 = from the clock (recorded in metadata.json)
+	Budgets   []string           `json:"budgets"` // durations; empty = the -budget default
+	Flags     map[string]string  `json:"flags"`   // flags shared by all methods
+	Methods   []ExperimentMethod `json:"methods"`
+	Trace     bool               `json:"trace"`
+}
+
+// ExperimentMethod is one -algo with fixed flags and a grid of flag values
+type ExperimentMethod struct {
+	Algo  string              `json:"algo"`
+	Flags map[string]string   `json:"flags"`
+	Grid  map[string][]string `json:"grid"`
+}
+
+// experimentFile is the metadata of one results CSV
+type experimentFile struct {
+	File     string            `json:"file"`
+	Trace    string            `json:"trace,omitempty"`
+	Instance string            `json:"instance"`
+	Algo     string            `json:"algo"`
+	Budget   string            `json:"budget,omitempty"`
+	Flags    map[string]string `json:"flags"`
+	Methods  []string          `json:"methods"`
+	Bound    int               `json:"lower_bound"`
+	Started  time.Time         `json:"started"`
+	Seconds  float64           `json:"seconds"`
+}
+
+type experimentMetadata struct {
+	Config    string           `json:"config"`
+	Seed      int64            `json:"seed"`
+	Runs      int              `json:"runs"`
+	GoVersion string           `json:"go_version"`
+	CPUs      int              `json:"cpus"`
+	Started   time.Time        `json:"started"`
+	Finished  time.Time        `json:"finished"`
+	Files     []experimentFile `json:"files"`
+}
+
+// algorithms whose runs do not depend on -budget
+var budgetFreeAlgos = map[string]bool{"ls": true, "construct": true, "vnd": true}
+
+// ReadExperimentConfig reads and checks an experiment file
+func ReadExperimentConfig(path string) (*ExperimentConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &ExperimentConfig{}
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if cfg.Out == "" || len(cfg.Instances) == 0 || len(cfg.Methods) == 0 || cfg.Runs <= 0 {
+		return nil, fmt.Errorf("%s: out, instances, methods and runs > 0 are required", path)
+	}
+	// paths in the file are relative to it
+	dir := filepath.Dir(path)
+	for i, p := range cfg.Instances {
+		if !filepath.IsAbs(p) {
+			cfg.Instances[i] = filepath.Join(dir, p)
+		}
+	}
+	if !filepath.IsAbs(cfg.Out) {
+		cfg.Out = filepath.Join(dir, cfg.Out)
+	}
+	for _, b := range cfg.Budgets {
+		if _, err := time.ParseDuration(b); err != nil {
+			return nil, fmt.Errorf("%s: budget %q: %v", path, b, err)
+		}
+	}
+	for _, m := range cfg.Methods {
+		for name := range m.Flags {
+			if lookupOption(name) == nil {
+				return nil, fmt.Errorf("%s: %s: unknown flag %q", path, m.Algo, name)
+			}
+		}
+		for name := range m.Grid {
+			if lookupOption(name) == nil {
+				return nil, fmt.Errorf("%s: %s: unknown grid flag %q", path, m.Algo, name)
+			}
+		}
+	}
+	for name := range cfg.Flags {
+		if lookupOption(name) == nil {
+			return nil, fmt.Errorf("%s: unknown flag %q", path, name)
+		}
+	}
+	// invalid values fail before any run
+	for _, m := range cfg.Methods {
+		for _, combo := range gridCombinations(m.Grid) {
+			if _, _, _, err := cfg.build(m, combo, ""); err != nil {
+				return nil, fmt.Errorf("%s: %v", path, err)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// build returns the settings, options and methods of one grid combination of method
+// entry em with budget b ("" = the default)
+func (cfg *ExperimentConfig) build(em ExperimentMethod, combo map[string]string, b string) (map[string]string, Options, []Method, error) {
+	layers := []map[string]string{cfg.Flags, em.Flags, combo, {"algo": em.Algo}}
+	if b != "" {
+		layers = append(layers, map[string]string{"budget": b})
+	}
+	applied := mergeSettings(layers...)
+	opts, err := DefaultOptions().With(applied)
+	if err != nil {
+		return nil, opts, nil, fmt.Errorf("%s: %v", em.Algo, err)
+	}
+	methods, err := BuildMethods(opts)
+	if err != nil {
+		return nil, opts, nil, fmt.Errorf("%s: %v", em.Algo, err)
+	}
+	return applied, opts, methods, nil
+}
+
+// gridCombinations returns every combination of the grid values (sorted by flag name)
+func gridCombinations(grid map[string][]string) []map[string]string {
+	names := make([]string, 0, len(grid))
+	for name := range grid {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	combos := []map[string]string{{}}
+	for _, name := range names {
+		var next []map[string]string
+		for _, c := range combos {
+			for _, v := range grid[name] {
+				n := map[string]string{name: v}
+				for k, x := range c {
+					n[k] = x
+				}
+				next = append(next, n)
+			}
+		}
+		combos = next
+	}
+	return combos
+}
+
+// mergeSettings merges "name=value" layers, later layers win
+func mergeSettings(layers ...map[string]string) map[string]string {
+	merged := map[string]string{}
+	for _, layer := range layers {
+		for name, v := range layer {
+			merged[name] = v
+		}
+	}
+	return merged
+}
+
+// RunExperiment runs the experiment file at path
+func RunExperiment(path string, resume bool) error {
+	cfg, err := ReadExperimentConfig(path)
+	if err != nil {
+		return err
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	meta := experimentMetadata{
+		Config: path, Seed: cfg.Seed, Runs: cfg.Runs,
+		GoVersion: runtime.Version(), CPUs: runtime.NumCPU(), Started: time.Now(),
+	}
+	finished := map[string]bool{}
+	if resume {
+		if err := readExperimentMetadata(cfg.Out, raw, &meta); err != nil {
+			return err
+		}
+		if cfg.Seed == 0 {
+			cfg.Seed = meta.Seed
+		}
+		for _, f := range meta.Files {
+			finished[f.File] = true
+		}
+	}
+	if cfg.Seed == 0 {
+		cfg.Seed = time.Now().UnixNano()
+	}
+	meta.Seed = cfg.Seed
+	if err := os.MkdirAll(cfg.Out, 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(cfg.Out, "config.json"), raw, 0o644); err != nil {
+		return err
+	}
+	// written before the first run, so a resume finds the seed
+	if err := writeExperimentMetadata(cfg.Out, meta); err != nil {
+		return err
+	}
+	budgets := cfg.Budgets
+	if len(budgets) == 0 {
+		budgets = []string{DefaultOptions().Budget.String()}
+	}
+	fmt.Printf("Experiment %s: seed %d, results in %s\n", path, cfg.Seed, cfg.Out)
+
+	for _, instPath := range cfg.Instances {
+		instName := strings.TrimSuffix(filepath.Base(instPath), filepath.Ext(instPath))
+		// instance options (-sub, -lbiters) come from the common flags
+		instOpts, err := DefaultOptions().With(cfg.Flags)
+		if err != nil {
+			return err
+		}
+		inst, err := ReadInstanceCSV(instPath)
+		if err != nil {
+			return err
+		}
+		if instOpts.Sub > 0 {
+			inst = SubInstance(inst, min(instOpts.Sub, inst.N))
+		}
+		bound := 0
+		if instOpts.LBIters > 0 {
+			ub, _ := GreedyRegretStart(inst, 0)
+			bound = LagrangianBound(inst, Objective(inst, ub), instOpts.LBIters)
+		}
+		fmt.Printf("Instance %s: N=%d, K=%d, lower bound %d\n", instName, inst.N, inst.K, bound)
+
+		for mi, em := range cfg.Methods {
+			entryBudgets := budgets
+			if budgetFreeAlgos[em.Algo] {
+				entryBudgets = []string{""}
+			}
+			for ci, combo := range gridCombinations(em.Grid) {
+				for _, b := range entryBudgets {
+					applied, opts, methods, err := cfg.build(em, combo, b)
+					if err != nil {
+						return err
+					}
+					base := fmt.Sprintf("%s_m%02d_%s", instName, mi, em.Algo)
+					if len(em.Grid) > 0 {
+						base += fmt.Sprintf("_g%02d", ci)
+					}
+					if b != "" {
+						base += "_" + b
+					}
+					if finished[base+".csv"] {
+						fmt.Printf("== %s (done)\n", base+".csv")
+						continue
+					}
+					file := experimentFile{
+						File: base + ".csv", Instance: instPath, Algo: em.Algo, Budget: b,
+						Flags: applied, Bound: bound, Started: time.Now(),
+					}
+					for _, m := range methods {
+						file.Methods = append(file.Methods, m.Name)
+					}
+					tracePath := ""
+					if cfg.Trace {
+						file.Trace = base + "_trace.csv"
+						tracePath = filepath.Join(cfg.Out, file.Trace)
+					}
+					fmt.Printf("== %s\n", file.File)
+					err = runMethods(inst, methods, cfg.Runs, cfg.Seed, opts.Workers,
+						filepath.Join(cfg.Out, file.File), tracePath, bound, resume)
+					if err != nil {
+						return err
+					}
+					file.Seconds = time.Since(file.Started).Seconds()
+					meta.Files = append(meta.Files, file)
+					// metadata is rewritten after every file, so an interrupted experiment still describes its results
+					meta.Finished = time.Now()
+					if err := writeExperimentMetadata(cfg.Out, meta); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	fmt.Printf("Experiment done: %d result files in %s\n", len(meta.Files), cfg.Out)
+	return nil
+}
+
+// readExperimentMetadata reads the metadata of an interrupted experiment in dir into meta;
+// the experiment must have been started from the same config
+func readExperimentMetadata(dir string, config []byte, meta *experimentMetadata) error {
+	old, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(old, config) {
+		return fmt.Errorf("%s was started from another config, resume needs the same one", dir)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "metadata.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, meta); err != nil {
+		return fmt.Errorf("%s: %v", dir, err)
+	}
+	return nil
+}
+
+func writeExperimentMetadata(dir string, meta experimentMetadata) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "metadata.json"), append(data, '\n'), 0o644)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeConfig writes an experiment file into dir
+func writeConfig(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestExperimentExample loads experiments/example.json and checks the methods every
+// entry builds
+func TestExperimentExample(t *testing.T) {
+	cfg, err := ReadExperimentConfig("experiments/example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"../TSPA.csv", "../TSPB.csv"}; !slices.Equal(cfg.Instances, want) {
+		t.Errorf("instances %v, want %v relative to the config", cfg.Instances, want)
+	}
+	var ls []string
+	for _, m := range LocalSearchMethods([]string{"random", "greedy"}) {
+		ls = append(ls, m.Name)
+	}
+	want := [][]string{
+		ls,
+		{"ils_steepest_intra:edges_perturb:double-bridge_accept:better", "ils_steepest_intra:edges_perturb:replace_accept:better"},
+		{"lns_destroy:adaptive_ls:false", "lns_destroy:adaptive_ls:true"},
+	}
+	for i, em := range cfg.Methods {
+		var names []string
+		for _, combo := range gridCombinations(em.Grid) {
+			_, _, methods, err := cfg.build(em, combo, cfg.Budgets[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, m := range methods {
+				names = append(names, m.Name)
+			}
+		}
+		slices.Sort(names)
+		slices.Sort(want[i])
+		if !slices.Equal(names, want[i]) {
+			t.Errorf("method entry %d (%s) builds %v, want %v", i, em.Algo, names, want[i])
+		}
+	}
+}
+
+// TestExperimentConfigRejects checks that malformed files and invalid methods fail when
+// the file is read, before any run
+func TestExperimentConfigRejects(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"unknown key":        `{"out": "o", "instances": ["i.csv"], "runs": 1, "methods": [{"algo": "ls"}], "repeats": 3}`,
+		"unknown method key": `{"out": "o", "instances": ["i.csv"], "runs": 1, "methods": [{"algo": "ls", "flag": {}}]}`,
+		"unknown flag":       `{"out": "o", "instances": ["i.csv"], "runs": 1, "methods": [{"algo": "ls", "flags": {"seed": "1"}}]}`,
+		"unknown grid flag":  `{"out": "o", "instances": ["i.csv"], "runs": 1, "methods": [{"algo": "ils", "grid": {"nope": ["1"]}}]}`,
+		"unknown algo":       `{"out": "o", "instances": ["i.csv"], "runs": 1, "methods": [{"algo": "nope"}]}`,
+		"invalid grid value": `{"out": "o", "instances": ["i.csv"], "runs": 1, "methods": [{"algo": "ils", "grid": {"perturb": ["replace", "nope"]}}]}`,
+		"invalid flag value": `{"out": "o", "instances": ["i.csv"], "runs": 1, "flags": {"pop": "many"}, "methods": [{"algo": "hea"}]}`,
+		"bad budget":         `{"out": "o", "instances": ["i.csv"], "runs": 1, "budgets": ["soon"], "methods": [{"algo": "ils"}]}`,
+		"no runs":            `{"out": "o", "instances": ["i.csv"], "methods": [{"algo": "ls"}]}`,
+	} {
+		if _, err := ReadExperimentConfig(writeConfig(t, dir, "config.json", content)); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+// TestRunExperiment runs a small experiment and checks the result files and metadata;
+// a resume of the finished experiment runs nothing
+func TestRunExperiment(t *testing.T) {
+	inst, err := filepath.Abs("../TSPA.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	config := `{
+	  "out": "results",
+	  "instances": ["` + inst + `"],
+	  "runs": 2,
+	  "seed": 3,
+	  "budgets": ["5ms"],
+	  "flags": {"sub": "30", "lbiters": "0"},
+	  "methods": [
+	    {"algo": "construct", "flags": {"starts": "greedy,hull"}},
+	    {"algo": "ils", "grid": {"perturb": ["replace", "reverse"]}}
+	  ]
+	}`
+	path := writeConfig(t, dir, "config.json", config)
+	if err := RunExperiment(path, false); err != nil {
+		t.Fatal(err)
+	}
+	readMeta := func() experimentMetadata {
+		data, err := os.ReadFile(filepath.Join(dir, "results", "metadata.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var meta experimentMetadata
+		if err := json.Unmarshal(data, &meta); err != nil {
+			t.Fatal(err)
+		}
+		return meta
+	}
+	meta := readMeta()
+	want := map[string][]string{
+		"TSPA_m00_construct.csv":   {"construct_start:greedy", "construct_start:hull"},
+		"TSPA_m01_ils_g00_5ms.csv": {"ils_steepest_intra:edges_perturb:replace_accept:better"},
+		"TSPA_m01_ils_g01_5ms.csv": {"ils_steepest_intra:edges_perturb:reverse_accept:better"},
+	}
+	if meta.Seed != 3 || len(meta.Files) != len(want) {
+		t.Fatalf("metadata: seed %d, %d files, want seed 3 and %d files", meta.Seed, len(meta.Files), len(want))
+	}
+	for _, f := range meta.Files {
+		if !slices.Equal(f.Methods, want[f.File]) {
+			t.Errorf("%s: methods %v, want %v", f.File, f.Methods, want[f.File])
+		}
+		if f.Flags["sub"] != "30" || f.Flags["algo"] != f.Algo {
+			t.Errorf("%s: recorded flags %v", f.File, f.Flags)
+		}
+		rows := readResults(t, filepath.Join(dir, "results", f.File))
+		if len(rows) != 2*len(f.Methods) {
+			t.Errorf("%s: %d rows, want %d", f.File, len(rows), 2*len(f.Methods))
+		}
+	}
+
+	if err := RunExperiment(path, true); err != nil {
+		t.Fatal(err)
+	}
+	if resumed := readMeta(); len(resumed.Files) != len(want) || !resumed.Files[0].Started.Equal(meta.Files[0].Started) {
+		t.Errorf("resuming a finished experiment changed its metadata")
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// GUIDED LOCAL SEARCH
+//
+// Features are the edges of the tour and the selected nodes. At every local optimum
+// the features with maximum utility cost/(1+penalty) get their penalty increased.
+// Local search runs on an augmented view of the instance, where
+//   dist'(a,b) = dist(a,b) + lambda*penalty(a,b)
+//   cost'(v)   = cost(v)   + lambda*penalty(v)
+// so the existing delta functions evaluate the augmented objective unchanged.
+// lambda = A * (objective of the first local optimum) / (2K), rounded, at least 1.
+
+// GLSOptions configures GuidedLocalSearch
+type GLSOptions struct {
+	A         float64 // lambda scaling
+	Mode      string  // local search: "steepest" or "greedy"
+	IntraMode string  // "nodes" or "edges"
+	Budget    time.Duration
+	Iters     int
+}
+
+// GLSMethod wraps GuidedLocalSearch as a runnable method starting from random solutions
+func GLSMethod(opts GLSOptions) Method {
+	return Method{
+		Name:      fmt.Sprintf("gls_a:%g_%s_intra:%s", opts.A, opts.Mode, opts.IntraMode),
+		StartType: "random",
+		Improve: func(inst *Instance, tour []int, inSel []bool, rnd *rand.Rand) RunResult {
+			return GuidedLocalSearch(inst, tour, inSel, opts, rnd)
+		},
+	}
+}
+
+// augmentedInstance returns a copy of inst whose Dist and Nodes can be penalised independently
+func augmentedInstance(inst *Instance) *Instance {
+	aug := &Instance{N: inst.N, K: inst.K, Nodes: append([]Node{}, inst.Nodes...), Dist: make([][]int, inst.N), Weights: inst.Weights}
+	for i := range inst.Dist {
+		aug.Dist[i] = append([]int{}, inst.Dist[i]...)
+	}
+	return aug
+}
+
+func GuidedLocalSearch(inst *Instance, tour []int, inSel []bool, opts GLSOptions, rnd *rand.Rand) RunResult {
+	stop := newBudget(opts.Budget, opts.Iters)
+	res := RunResult{}
+	N := inst.N
+
+	aug := augmentedInstance(inst)
+	edgePenalty := make([][]int, N)
+	for i := range edgePenalty {
+		edgePenalty[i] = make([]int, N)
+	}
+	nodePenalty := make([]int, N)
+
+	cur, curSel, evals, imps := RunLocalSearch(inst, tour, inSel, opts.Mode, opts.IntraMode, rnd)
+	res.Evals += evals
+	res.Improvements += imps
+	best := append([]int{}, cur...)
+	bestObj := Objective(inst, cur)
+	lambda := int(math.Max(1, math.Round(opts.A*float64(bestObj)/float64(2*inst.K))))
+
+	for iter := 0; stop.left(res.Iters); iter++ {
+		res.Iters++
+		// penalise the features of the current local optimum with maximum utility
+		K := len(cur)
+		maxUtil := -1.0
+		for i, a := range cur {
+			b := cur[(i+1)%K]
+			maxUtil = math.Max(maxUtil, float64(inst.Dist[a][b])/float64(1+edgePenalty[a][b]))
+			maxUtil = math.Max(maxUtil, float64(inst.Nodes[a].Cost)/float64(1+nodePenalty[a]))
+		}
+		for i, a := range cur {
+			b := cur[(i+1)%K]
+			if float64(inst.Dist[a][b])/float64(1+edgePenalty[a][b]) == maxUtil {
+				edgePenalty[a][b]++
+				edgePenalty[b][a]++
+				aug.Dist[a][b] += lambda
+				aug.Dist[b][a] += lambda
+			}
+			if float64(inst.Nodes[a].Cost)/float64(1+nodePenalty[a]) == maxUtil {
+				nodePenalty[a]++
+				aug.Nodes[a].Cost += lambda
+			}
+		}
+
+		cur, curSel, evals, imps = RunLocalSearch(aug, cur, curSel, opts.Mode, opts.IntraMode, rnd)
+		res.Evals += evals
+		res.Improvements += imps
+		res.Restarts++
+
+		// the true objective decides the best solution, the augmented one only guides the search
+		obj := Objective(inst, cur)
+		if obj < bestObj {
+			best, bestObj = append(best[:0], cur...), obj
+		}
+		res.Trace = append(res.Trace, TracePoint{Iter: iter, Objective: obj, Best: bestObj, Value: float64(Objective(aug, cur))})
+	}
+	res.Tour = best
+	return res
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// GRASP
+//
+// Randomized weighted 2-regret construction followed by local search, repeated until
+// the time budget is used. Instead of always inserting the best scored candidate, the
+// node is drawn uniformly from a restricted candidate list (RCL):
+// - "cardinality": the RCLSize best scored candidates,
+// - "alpha": candidates with score >= max - Alpha*(max-min),
+// - "reactive": like "alpha", but Alpha is drawn from graspAlphas with probabilities
+//   updated from the average objective obtained with each value.
+
+// GRASPOptions configures GRASP
+type GRASPOptions struct {
+	RCL       string  // one of RCLTypes
+	RCLSize   int     // candidates kept by "cardinality"
+	Alpha     float64 // threshold of "alpha"
+	Mode      string  // local search: "steepest" or "greedy"
+	IntraMode string  // "nodes" or "edges"
+	Budget    time.Duration
+	Iters     int
+}
+
+// RCLTypes are the values of GRASPOptions.RCL
+var RCLTypes = []string{"cardinality", "alpha", "reactive"}
+
+// reactive GRASP settings
+var graspAlphas = []float64{0, 0.05, 0.1, 0.2, 0.3, 0.5}
+
+const (
+	graspReactiveEvery = 20 // iterations between probability updates
+	graspReactiveDelta = 10 // amplification exponent of (best/average)
+)
+
+// GRASPMethod wraps GRASP as a runnable method
+func GRASPMethod(opts GRASPOptions) Method {
+	name := fmt.Sprintf("grasp_rcl:%s", opts.RCL)
+	switch opts.RCL {
+	case "cardinality":
+		name += fmt.Sprintf("_size:%d", opts.RCLSize)
+	case "alpha":
+		name += fmt.Sprintf("_alpha:%g", opts.Alpha)
+	}
+	return Method{
+		Name:      name,
+		StartType: "random",
+		Improve: func(inst *Instance, tour []int, inSel []bool, rnd *rand.Rand) RunResult {
+			return GRASP(inst, tour, inSel, opts, rnd)
+		},
+	}
+}
+
+// GraspConstruct builds a solution with weighted 2-regret insertion picking every node from the RCL
+func GraspConstruct(inst *Instance, startNode int, rcl string, size int, alpha float64, rnd *rand.Rand) ([]int, []bool) {
+	tour, selected := regretStartPair(inst, startNode)
+	c := newRegretCache(inst, tour, selected)
+	for len(c.tour) < inst.K {
+		cands := c.sorted()
+		limit := 1
+		if rcl == "cardinality" {
+			limit = min(size, len(cands))
+		} else {
+			// cands are sorted by decreasing score
+			threshold := cands[0].score - alpha*(cands[0].score-cands[len(cands)-1].score)
+			for limit < len(cands) && cands[limit].score >= threshold {
+				limit++
+			}
+		}
+		if limit < 1 {
+			limit = 1
+		}
+		ch := cands[rnd.Intn(limit)]
+		c.insert(ch.node, ch.bestPos)
+	}
+	return c.tour, selected
+}
+
+func GRASP(inst *Instance, tour []int, inSel []bool, opts GRASPOptions, rnd *rand.Rand) RunResult {
+	stop := newBudget(opts.Budget, opts.Iters)
+	res := RunResult{}
+
+	// the starting solution is improved first and serves as the initial best
+	best, _, evals, imps := RunLocalSearch(inst, tour, inSel, opts.Mode, opts.IntraMode, rnd)
+	res.Evals += evals
+	res.Improvements += imps
+	bestObj := Objective(inst, best)
+
+	probs := make([]float64, len(graspAlphas))
+	sums := make([]float64, len(graspAlphas))
+	counts := make([]int, len(graspAlphas))
+	for i := range probs {
+		probs[i] = 1
+	}
+
+	for iter := 0; stop.left(res.Iters); iter++ {
+		res.Iters++
+		alpha, alphaIdx := opts.Alpha, -1
+		if opts.RCL == "reactive" {
+			alphaIdx = rouletteSelect(probs, rnd)
+			alpha = graspAlphas[alphaIdx]
+		}
+
+		t, s := GraspConstruct(inst, rnd.Intn(inst.N), opts.RCL, opts.RCLSize, alpha, rnd)
+		t, _, evals, imps = RunLocalSearch(inst, t, s, opts.Mode, opts.IntraMode, rnd)
+		res.Evals += evals
+		res.Improvements += imps
+		res.Restarts++
+		obj := Objective(inst, t)
+		if obj < bestObj {
+			best, bestObj = t, obj
+		}
+
+		if alphaIdx >= 0 {
+			sums[alphaIdx] += float64(obj)
+			counts[alphaIdx]++
+			if (iter+1)%graspReactiveEvery == 0 {
+				for i := range probs {
+					if counts[i] > 0 {
+						probs[i] = math.Pow(float64(bestObj)/(sums[i]/float64(counts[i])), graspReactiveDelta)
+					}
+				}
+			}
+			res.Trace = append(res.Trace, TracePoint{Iter: iter, Objective: obj, Best: bestObj, Value: alpha})
+		}
+	}
+	res.Tour = best
+	return res
+}
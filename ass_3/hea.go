@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// HYBRID EVOLUTIONARY ALGORITHM (steady state)
+//
+// The population holds PopSize distinct local optima. Each generation two different
+// parents are drawn uniformly, recombined, optionally improved by local search and
+// the child replaces the worst member if it is better and its objective is not
+// already present in the population.
+
+// HEAOptions configures HybridEvolutionary
+type HEAOptions struct {
+	Crossover   string        // name in Recombinations
+	PopSize     int           // elite population size
+	LocalSearch bool          // run local search on every offspring
+	Mode        string        // local search: "steepest" or "greedy"
+	IntraMode   string        // "nodes" or "edges"
+	Budget      time.Duration // total running time
+	Iters       int           // exact number of iterations instead of Budget (0 = off)
+}
+
+// Individual is a member of an evolutionary population
+type Individual struct {
+	Tour  []int
+	InSel []bool
+	Obj   int
+}
+
+// HEAMethod wraps HybridEvolutionary as a runnable method
+func HEAMethod(opts HEAOptions) Method {
+	return Method{
+		Name:      fmt.Sprintf("hea_xover:%s_pop:%d_ls:%t", opts.Crossover, opts.PopSize, opts.LocalSearch),
+		StartType: "random",
+		Improve: func(inst *Instance, tour []int, inSel []bool, rnd *rand.Rand) RunResult {
+			return HybridEvolutionary(inst, tour, inSel, opts, rnd)
+		},
+	}
+}
+
+// hasObjective reports whether some individual already has the objective value
+func hasObjective(pop []Individual, obj int) bool {
+	for _, ind := range pop {
+		if ind.Obj == obj {
+			return true
+		}
+	}
+	return false
+}
+
+// worstIndividual returns the index of the worst individual
+func worstIndividual(pop []Individual) int {
+	worst := 0
+	for i := range pop {
+		if pop[i].Obj > pop[worst].Obj {
+			worst = i
+		}
+	}
+	return worst
+}
+
+// bestIndividual returns the index of the best individual
+func bestIndividual(pop []Individual) int {
+	best := 0
+	for i := range pop {
+		if pop[i].Obj < pop[best].Obj {
+			best = i
+		}
+	}
+	return best
+}
+
+// initPopulation fills a population with distinct local optima; the given starting solution
+// is the first member and is added even when the budget is already used, so the
+// population is never empty
+func initPopulation(inst *Instance, tour []int, inSel []bool, size int, mode string, intraMode string, stop budget, rnd *rand.Rand, res *RunResult) []Individual {
+	pop := make([]Individual, 0, size)
+	for first := true; first || len(pop) < size && stop.left(res.Iters); first = false {
+		res.Iters++
+		if !first {
+			tour, inSel = RandomStart(inst, rnd)
+		}
+		t, s, evals, imps := RunLocalSearch(inst, tour, inSel, mode, intraMode, rnd)
+		res.Evals += evals
+		res.Improvements += imps
+		res.Restarts++
+		obj := Objective(inst, t)
+		if !hasObjective(pop, obj) {
+			pop = append(pop, Individual{t, s, obj})
+		}
+	}
+	return pop
+}
+
+func HybridEvolutionary(inst *Instance, tour []int, inSel []bool, opts HEAOptions, rnd *rand.Rand) RunResult {
+	stop := newBudget(opts.Budget, opts.Iters)
+	res := RunResult{}
+
+	pop := initPopulation(inst, tour, inSel, opts.PopSize, opts.Mode, opts.IntraMode, stop, rnd, &res)
+	recombine := Recombinations[opts.Crossover]
+
+	for len(pop) >= 2 && stop.left(res.Iters) {
+		res.Iters++
+		i := rnd.Intn(len(pop))
+		j := rnd.Intn(len(pop) - 1)
+		if j >= i {
+			j++
+		}
+		child, childSel := recombine(inst, pop[i].Tour, pop[j].Tour, rnd)
+		if opts.LocalSearch {
+			var evals, imps int
+			child, childSel, evals, imps = RunLocalSearch(inst, child, childSel, opts.Mode, opts.IntraMode, rnd)
+			res.Evals += evals
+			res.Improvements += imps
+			res.Restarts++
+		}
+		obj := Objective(inst, child)
+		worst := worstIndividual(pop)
+		if obj < pop[worst].Obj && !hasObjective(pop, obj) {
+			pop[worst] = Individual{child, childSel, obj}
+		}
+	}
+
+	res.Tour = pop[bestIndividual(pop)].Tour
+	return res
+}
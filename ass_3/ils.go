@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ITERATED LOCAL SEARCH
+//
+// Start from a local optimum, then repeat until the time budget is used:
+//   perturb the current solution -> run local search -> accept or reject.
+// The best solution seen is returned.
+// With EliteSize > 0 every local optimum is offered to an elite pool, and every
+// RelinkEvery iterations path relinking runs between two random elite members.
+
+// ILSOptions configures IteratedLocalSearch
+type ILSOptions struct {
+	Mode      string        // local search: "steepest" or "greedy"
+	IntraMode string        // "nodes" or "edges"
+	Perturb   string        // "double-bridge", "reverse" or "replace"
+	Strength  int           // number of nodes replaced by the "replace" perturbation (at most K)
+	Accept    string        // "better", "equal" or "sa"
+	Temp      float64       // initial temperature for "sa" acceptance
+	Cooling   float64       // temperature multiplier applied after every iteration ("sa")
+	Budget    time.Duration // total running time
+	Iters     int           // exact number of iterations instead of Budget (0 = off)
+
+	EliteSize   int       // elite pool size for path relinking (0 = off)
+	RelinkEvery int       // iterations between path relinking steps
+	Relink      PROptions // path relinking settings
+}
+
+// PerturbNames and AcceptRules are the values of ILSOptions.Perturb and ILSOptions.Accept
+var (
+	PerturbNames = []string{"double-bridge", "reverse", "replace"}
+	AcceptRules  = []string{"better", "equal", "sa"}
+)
+
+// ILSMethod wraps IteratedLocalSearch as a runnable method starting from random solutions
+func ILSMethod(opts ILSOptions) Method {
+	name := fmt.Sprintf("ils_%s_intra:%s_perturb:%s_accept:%s", opts.Mode, opts.IntraMode, opts.Perturb, opts.Accept)
+	if opts.EliteSize > 0 {
+		name += fmt.Sprintf("_elite:%d_relink:%d", opts.EliteSize, opts.RelinkEvery)
+	}
+	return Method{
+		Name:      name,
+		StartType: "random",
+		Improve: func(inst *Instance, tour []int, inSel []bool, rnd *rand.Rand) RunResult {
+			return IteratedLocalSearch(inst, tour, inSel, opts, rnd)
+		},
+	}
+}
+
+func IteratedLocalSearch(inst *Instance, tour []int, inSel []bool, opts ILSOptions, rnd *rand.Rand) RunResult {
+	stop := newBudget(opts.Budget, opts.Iters)
+	res := RunResult{}
+
+	cur, curSel, evals, imps := RunLocalSearch(inst, tour, inSel, opts.Mode, opts.IntraMode, rnd)
+	res.Evals += evals
+	res.Improvements += imps
+	curObj := Objective(inst, cur)
+
+	best := append([]int{}, cur...)
+	bestObj := curObj
+	temp := opts.Temp
+	pool := &ElitePool{Size: opts.EliteSize}
+	pool.Offer(inst, cur, curSel)
+
+	for iter := 1; stop.left(res.Iters); iter++ {
+		res.Iters++
+		cand := append([]int{}, cur...)
+		candSel := append([]bool{}, curSel...)
+		Perturb(inst, cand, candSel, opts.Perturb, opts.Strength, rnd)
+
+		cand, candSel, evals, imps = RunLocalSearch(inst, cand, candSel, opts.Mode, opts.IntraMode, rnd)
+		res.Evals += evals
+		res.Improvements += imps
+		res.Restarts++
+		candObj := Objective(inst, cand)
+
+		if acceptMove(opts.Accept, candObj-curObj, temp, rnd) {
+			cur, curSel, curObj = cand, candSel, candObj
+		}
+		if opts.EliteSize > 0 {
+			pool.Offer(inst, cand, candSel)
+			if opts.RelinkEvery > 0 && iter%opts.RelinkEvery == 0 && len(pool.Members) >= 2 {
+				perm := rnd.Perm(len(pool.Members))
+				src, dst := pool.Members[perm[0]], pool.Members[perm[1]]
+				pr, prSel, evals, lsRuns := PathRelink(inst, src.Tour, dst.Tour, opts.Relink, rnd)
+				res.Evals += evals
+				res.Restarts += lsRuns
+				pool.Offer(inst, pr, prSel)
+				if prObj := Objective(inst, pr); prObj < curObj {
+					cur, curSel, curObj = pr, prSel, prObj
+				}
+			}
+		}
+		if curObj < bestObj {
+			best = append(best[:0], cur...)
+			bestObj = curObj
+		}
+		temp *= opts.Cooling
+	}
+	res.Tour = best
+	return res
+}
+
+// acceptMove decides whether a new solution replaces the current one, delta = new - current
+func acceptMove(rule string, delta int, temp float64, rnd *rand.Rand) bool {
+	switch rule {
+	case "equal":
+		return delta <= 0
+	case "sa":
+		if delta <= 0 {
+			return true
+		}
+		if temp <= 0 {
+			return false
+		}
+		return rnd.Float64() < math.Exp(-float64(delta)/temp)
+	default: // "better"
+		return delta < 0
+	}
+}
+
+// Perturb modifies tour/inSel in-place with the given perturbation
+func Perturb(inst *Instance, tour []int, inSel []bool, kind string, strength int, rnd *rand.Rand) {
+	switch kind {
+	case "reverse":
+		reverseRandomSegment(tour, rnd)
+	case "replace":
+		replaceRandomNodes(inst, tour, inSel, strength, rnd)
+	default: // "double-bridge"
+		doubleBridge(tour, rnd)
+	}
+}
+
+// double-bridge: cut the tour into A B C D and reconnect as A C B D
+func doubleBridge(tour []int, rnd *rand.Rand) {
+	K := len(tour)
+	if K < 8 {
+		reverseRandomSegment(tour, rnd)
+		return
+	}
+	// three distinct cut points 0 < p1 < p2 < p3 < K
+	cuts := rnd.Perm(K - 1)[:3]
+	for i := range cuts {
+		cuts[i]++
+	}
+	for i := 0; i < 3; i++ {
+		for j := i + 1; j < 3; j++ {
+			if cuts[j] < cuts[i] {
+				cuts[i], cuts[j] = cuts[j], cuts[i]
+			}
+		}
+	}
+	p1, p2, p3 := cuts[0], cuts[1], cuts[2]
+	newT := make([]int, 0, K)
+	newT = append(newT, tour[:p1]...)
+	newT = append(newT, tour[p2:p3]...)
+	newT = append(newT, tour[p1:p2]...)
+	newT = append(newT, tour[p3:]...)
+	copy(tour, newT)
+}
+
+// reverse a random segment tour[i..j]
+func reverseRandomSegment(tour []int, rnd *rand.Rand) {
+	K := len(tour)
+	if K < 3 {
+		return
+	}
+	i := rnd.Intn(K - 1)
+	j := i + 1 + rnd.Intn(K-i-1)
+	for a, b := i, j; a < b; a, b = a+1, b-1 {
+		tour[a], tour[b] = tour[b], tour[a]
+	}
+}
+
+// replace k random tour positions with random unselected nodes
+func replaceRandomNodes(inst *Instance, tour []int, inSel []bool, k int, rnd *rand.Rand) {
+	unselected := make([]int, 0, inst.N-len(tour))
+	for v := 0; v < inst.N; v++ {
+		if !inSel[v] {
+			unselected = append(unselected, v)
+		}
+	}
+	if k > len(tour) {
+		k = len(tour)
+	}
+	if k > len(unselected) {
+		k = len(unselected)
+	}
+	rnd.Shuffle(len(unselected), func(i, j int) { unselected[i], unselected[j] = unselected[j], unselected[i] })
+	positions := rnd.Perm(len(tour))[:k]
+	for i, pos := range positions {
+		u := unselected[i]
+		inSel[tour[pos]] = false
+		inSel[u] = true
+		tour[pos] = u
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// LARGE NEIGHBOURHOOD SEARCH
+//
+// Repeat until the time budget is used:
+//   destroy 20-30% of the current tour -> repair with weighted 2-regret insertion
+//   -> (optional) local search -> accept if better than the current solution.
+// In adaptive mode (ALNS) the destroy operator is drawn by roulette wheel and the
+// weights are updated from the success of each operator; success rates and weights are
+// written to the trace after every segment.
+
+var destroyOperators = []string{"random", "worst", "related", "segment"}
+
+// LNSOptions configures LargeNeighbourhoodSearch
+type LNSOptions struct {
+	Destroy     string        // one of destroyOperators or "adaptive"
+	LocalSearch bool          // run local search after every repair
+	Mode        string        // local search: "steepest" or "greedy"
+	IntraMode   string        // "nodes" or "edges"
+	Budget      time.Duration // total running time
+	Iters       int           // exact number of iterations instead of Budget (0 = off)
+}
+
+// ALNS scores: new best, better than current, tried
+const (
+	alnsScoreBest    = 3.0
+	alnsScoreBetter  = 2.0
+	alnsScoreTried   = 0.0
+	alnsReaction     = 0.2
+	alnsSegmentIters = 50
+)
+
+// LNSMethod wraps LargeNeighbourhoodSearch as a runnable method starting from random solutions
+func LNSMethod(opts LNSOptions) Method {
+	return Method{
+		Name:      fmt.Sprintf("lns_destroy:%s_ls:%t", opts.Destroy, opts.LocalSearch),
+		StartType: "random",
+		Improve: func(inst *Instance, tour []int, inSel []bool, rnd *rand.Rand) RunResult {
+			return LargeNeighbourhoodSearch(inst, tour, inSel, opts, rnd)
+		},
+	}
+}
+
+func LargeNeighbourhoodSearch(inst *Instance, tour []int, inSel []bool, opts LNSOptions, rnd *rand.Rand) RunResult {
+	stop := newBudget(opts.Budget, opts.Iters)
+	res := RunResult{}
+
+	// the starting solution is always a local optimum
+	cur, curSel, evals, imps := RunLocalSearch(inst, tour, inSel, opts.Mode, opts.IntraMode, rnd)
+	res.Evals += evals
+	res.Improvements += imps
+	curObj := Objective(inst, cur)
+	best := append([]int{}, cur...)
+	bestObj := curObj
+
+	adaptive := opts.Destroy == "adaptive"
+	nOps := len(destroyOperators)
+	weights := make([]float64, nOps)
+	segScore := make([]float64, nOps)
+	segUses := make([]int, nOps)
+	uses := make([]int, nOps)
+	successes := make([]int, nOps)
+	for i := range weights {
+		weights[i] = 1
+	}
+	// operator success rates (%) and weights go to the trace, labelled by operator
+	logOperators := func(iter int) {
+		for i, name := range destroyOperators {
+			rate := 0.0
+			if uses[i] > 0 {
+				rate = 100 * float64(successes[i]) / float64(uses[i])
+			}
+			res.Trace = append(res.Trace,
+				TracePoint{Iter: iter, Objective: curObj, Best: bestObj, Value: rate, Label: "success:" + name},
+				TracePoint{Iter: iter, Objective: curObj, Best: bestObj, Value: weights[i], Label: "weight:" + name})
+		}
+	}
+
+	for iter := 0; stop.left(res.Iters); iter++ {
+		res.Iters++
+		op := opts.Destroy
+		opIdx := -1
+		if adaptive {
+			opIdx = rouletteSelect(weights, rnd)
+			op = destroyOperators[opIdx]
+		}
+
+		cand := append([]int{}, cur...)
+		candSel := append([]bool{}, curSel...)
+		// remove between 20% and 30% of the tour
+		m := int(math.Round(float64(len(cand)) * (0.2 + 0.1*rnd.Float64())))
+		cand = Destroy(inst, cand, candSel, op, m, rnd)
+		cand = RegretRepair(inst, cand, candSel)
+		if opts.LocalSearch {
+			cand, candSel, evals, imps = RunLocalSearch(inst, cand, candSel, opts.Mode, opts.IntraMode, rnd)
+			res.Evals += evals
+			res.Improvements += imps
+			res.Restarts++
+		}
+		candObj := Objective(inst, cand)
+
+		score := alnsScoreTried
+		if candObj < curObj {
+			score = alnsScoreBetter
+			if candObj < bestObj {
+				score = alnsScoreBest
+			}
+			cur, curSel, curObj = cand, candSel, candObj
+		}
+		if curObj < bestObj {
+			best = append(best[:0], cur...)
+			bestObj = curObj
+		}
+
+		if adaptive {
+			uses[opIdx]++
+			segUses[opIdx]++
+			segScore[opIdx] += score
+			if score > alnsScoreTried {
+				successes[opIdx]++
+			}
+			if (iter+1)%alnsSegmentIters == 0 {
+				for i := range weights {
+					if segUses[i] > 0 {
+						weights[i] = (1-alnsReaction)*weights[i] + alnsReaction*segScore[i]/float64(segUses[i])
+					}
+					// keep every operator selectable
+					weights[i] = math.Max(weights[i], 0.05)
+					segScore[i] = 0
+					segUses[i] = 0
+				}
+				logOperators(iter + 1)
+			}
+		}
+	}
+
+	if adaptive && res.Iters%alnsSegmentIters != 0 {
+		logOperators(res.Iters)
+	}
+
+	res.Tour = best
+	return res
+}
+
+func rouletteSelect(weights []float64, rnd *rand.Rand) int {
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+	r := rnd.Float64() * total
+	for i, w := range weights {
+		r -= w
+		if r < 0 {
+			return i
+		}
+	}
+	return len(weights) - 1
+}
+
+// Destroy removes m nodes from the tour with the given operator; inSel is updated in-place
+func Destroy(inst *Instance, tour []int, inSel []bool, op string, m int, rnd *rand.Rand) []int {
+	K := len(tour)
+	if m > K-2 {
+		m = K - 2
+	}
+	if m <= 0 {
+		return tour
+	}
+	remove := make([]bool, K) // by tour position
+
+	switch op {
+	case "worst":
+		// removal gain of each position; randomized so the same nodes are not always removed
+		type posGain struct{ pos, gain int }
+		gains := make([]posGain, K)
+		for pos := 0; pos < K; pos++ {
+			prev := tour[mod(pos-1, K)]
+			v := tour[pos]
+			next := tour[mod(pos+1, K)]
+			g := inst.Dist[prev][v] + inst.Dist[v][next] - inst.Dist[prev][next] + inst.Nodes[v].Cost
+			gains[pos] = posGain{pos, g}
+		}
+		sort.Slice(gains, func(a, b int) bool { return gains[a].gain > gains[b].gain })
+		for removed := 0; removed < m; removed++ {
+			idx := int(math.Pow(rnd.Float64(), 3) * float64(len(gains)))
+			remove[gains[idx].pos] = true
+			gains = append(gains[:idx], gains[idx+1:]...)
+		}
+	case "related":
+		// a random seed node and its nearest selected nodes
+		seed := tour[rnd.Intn(K)]
+		order := rnd.Perm(K)
+		sort.SliceStable(order, func(a, b int) bool {
+			return inst.Dist[seed][tour[order[a]]] < inst.Dist[seed][tour[order[b]]]
+		})
+		for _, pos := range order[:m] {
+			remove[pos] = true
+		}
+	case "segment":
+		first := rnd.Intn(K)
+		for i := 0; i < m; i++ {
+			remove[(first+i)%K] = true
+		}
+	default: // "random"
+		for _, pos := range rnd.Perm(K)[:m] {
+			remove[pos] = true
+		}
+	}
+
+	newT := make([]int, 0, K-m)
+	for pos, v := range tour {
+		if remove[pos] {
+			inSel[v] = false
+		} else {
+			newT = append(newT, v)
+		}
+	}
+	return newT
+}
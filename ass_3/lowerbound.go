@@ -0,0 +1,106 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+// LOWER BOUND (Lagrangian relaxation)
+//
+// Every cycle through a selected set S uses exactly two edges at every node of S.
+// With multipliers pi on the degree constraints, edge costs become
+//   d'(i,j) = d(i,j) + pi_i + pi_j
+// and the objective of any feasible solution equals
+//   sum of d' over its edges - 2*sum_{v in S} pi_v + sum_{v in S} cost(v).
+// Charging every edge half to each endpoint, the edges of v cost at least
+// (d'1(v) + d'2(v))/2, the two cheapest modified edges of v. So for any pi
+//   L(pi) = min over |S| = K of sum_{v in S} [cost(v) - 2*pi_v + (d'1(v) + d'2(v))/2]
+// is a lower bound; it is maximized over pi by subgradient optimization.
+
+// LagrangianBound returns a lower bound on the optimal objective; upper is the
+// objective of any feasible solution (used for the step size)
+func LagrangianBound(inst *Instance, upper int, iters int) int {
+	N, K := inst.N, inst.K
+	pi := make([]float64, N)
+	g := make([]float64, N)
+	vals := make([]float64, N)
+	first := make([]int, N)
+	second := make([]int, N)
+	order := make([]int, N)
+
+	best := math.Inf(-1)
+	mu := 2.0
+	sinceImprove := 0
+
+	for it := 0; it < iters; it++ {
+		// two cheapest modified edges of every node
+		for v := 0; v < N; v++ {
+			b1, b2 := math.Inf(1), math.Inf(1)
+			for u := 0; u < N; u++ {
+				if u == v {
+					continue
+				}
+				d := float64(inst.Dist[v][u]) + pi[v] + pi[u]
+				if d < b1 {
+					b2, second[v] = b1, first[v]
+					b1, first[v] = d, u
+				} else if d < b2 {
+					b2, second[v] = d, u
+				}
+			}
+			vals[v] = float64(inst.Nodes[v].Cost) - 2*pi[v] + (b1+b2)/2
+			order[v] = v
+		}
+		sort.Slice(order, func(a, b int) bool { return vals[order[a]] < vals[order[b]] })
+		L := 0.0
+		for _, v := range order[:K] {
+			L += vals[v]
+		}
+		if L > best+1e-9 {
+			best = L
+			sinceImprove = 0
+		} else if sinceImprove++; sinceImprove >= 50 {
+			mu /= 2
+			sinceImprove = 0
+		}
+
+		// subgradient: relaxed degree minus 2 for selected nodes
+		for v := range g {
+			g[v] = 0
+		}
+		for _, v := range order[:K] {
+			g[v] += 1 - 2 // own two half-edges minus the required degree 2
+			g[first[v]] += 0.5
+			g[second[v]] += 0.5
+		}
+		norm := 0.0
+		for _, x := range g {
+			norm += x * x
+		}
+		if norm == 0 || mu < 1e-6 {
+			break
+		}
+		step := mu * (float64(upper) - L) / norm
+		for v := range pi {
+			pi[v] += step * g[v]
+		}
+	}
+	return int(math.Ceil(best - 1e-6))
+}
+
+// GapToBound is the relative gap of an objective to a lower bound, in percent
+func GapToBound(obj int, bound int) float64 {
+	if bound <= 0 {
+		return math.NaN()
+	}
+	return 100 * float64(obj-bound) / float64(bound)
+}
+
+// gapColumn formats the gap for the results CSV (empty without a bound)
+func gapColumn(obj int, bound int) string {
+	if bound <= 0 {
+		return ""
+	}
+	return strconv.FormatFloat(GapToBound(obj, bound), 'f', 3, 64)
+}
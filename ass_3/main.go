@@ -9,7 +9,7 @@ import (
 	"math"
 	"math/rand"
 	"os"
-	"sort"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
@@ -27,6 +27,8 @@ type Instance struct {
 	Dist  [][]int // distance matrix (rounded Euclidean)
 	N     int
 	K     int // number of nodes to select (ceil(N/2))
+
+	Weights RegretWeights // score of the weighted regret constructions
 }
 
 // Result row to write to CSV
@@ -77,7 +79,7 @@ func ReadInstanceCSV(path string) (*Instance, error) {
 		}
 		nodes = append(nodes, Node{X: x, Y: y, Cost: cost})
 	}
-	inst := &Instance{Nodes: nodes, N: len(nodes)}
+	inst := &Instance{Nodes: nodes, N: len(nodes), Weights: DefaultRegretWeights}
 	// compute distance matrix immediately
 	inst.Dist = make([][]int, inst.N)
 	for i := 0; i < inst.N; i++ {
@@ -124,6 +126,34 @@ func SelectedCosts(nodes []Node, tour []int) int {
 	return sum
 }
 
+// ValidateSolution checks that tour is a cycle of K distinct nodes and inSel matches it
+func ValidateSolution(inst *Instance, tour []int, inSel []bool) error {
+	if len(tour) != inst.K {
+		return fmt.Errorf("tour has %d nodes, want %d", len(tour), inst.K)
+	}
+	seen := make([]bool, inst.N)
+	for _, v := range tour {
+		if v < 0 || v >= inst.N {
+			return fmt.Errorf("node %d out of range", v)
+		}
+		if seen[v] {
+			return fmt.Errorf("node %d visited twice", v)
+		}
+		seen[v] = true
+	}
+	for v := 0; v < inst.N; v++ {
+		if inSel[v] != seen[v] {
+			return fmt.Errorf("selection of node %d does not match the tour", v)
+		}
+	}
+	return nil
+}
+
+// Objective = tour length + costs of the selected nodes
+func Objective(inst *Instance, tour []int) int {
+	return TourLength(inst.Dist, tour) + SelectedCosts(inst.Nodes, tour)
+}
+
 // CREATE STARTING SOLUTIONS
 
 // Random starting solution: choose K distinct nodes uniformly, and random order
@@ -183,10 +213,41 @@ func insertAt(tour []int, pos int, node int) []int {
 
 // Greedy construction using regret-2 insertion. Start from a specified starting node index.
 func GreedyRegretStart(inst *Instance, startNode int) ([]int, []bool) {
-	k := inst.K
-	D:= inst.Dist
-	alpha := 1.0
-	beta := 1.0
+	tour, selected := regretStartPair(inst, startNode)
+	tour = RegretRepair(inst, tour, selected)
+	return tour, selected
+}
+
+// Greedy construction using pure 2-regret insertion (largest regret first, ties by cheaper insertion)
+func RegretOnlyStart(inst *Instance, startNode int) ([]int, []bool) {
+	tour, selected := regretStartPair(inst, startNode)
+	c := newRegretCache(inst, tour, selected)
+	moreRegret := func(x, y regretCand) bool {
+		rx, ry := x.secondTot-x.bestTot, y.secondTot-y.bestTot
+		return rx > ry || (rx == ry && x.bestTot < y.bestTot)
+	}
+	for len(c.tour) < inst.K {
+		cands := c.candidates()
+		ch, tie := pickFirst(cands, moreRegret)
+		if tie {
+			// full ties keep the order of the score sort
+			sortByScore(cands)
+			ch = cands[0]
+			for _, x := range cands[1:] {
+				if moreRegret(x, ch) {
+					ch = x
+				}
+			}
+		}
+		c.insert(ch.node, ch.bestPos)
+	}
+	return c.tour, selected
+}
+
+// regretStartPair returns the two-node tour the regret construction starts from:
+// startNode and its nearest neighbour (distance + cost)
+func regretStartPair(inst *Instance, startNode int) ([]int, []bool) {
+	D := inst.Dist
 	nodes := inst.Nodes
 	n := len(nodes)
 	selected := make([]bool, n)
@@ -205,45 +266,18 @@ func GreedyRegretStart(inst *Instance, startNode int) ([]int, []bool) {
 		}
 	}
 	selected[bestJ] = true
-	tour := []int{startNode, bestJ}
+	return []int{startNode, bestJ}, selected
+}
 
-	for countSelected(selected) < k {
-		type cand struct {
-			node, bestTot, secondTot, bestPos int
-			score                             float64
-		}
-		var cands []cand
-		for v := 0; v < n; v++ {
-			if selected[v] {
-				continue
-			}
-			bestInc, bestPos := bestInsertion(v, tour, D)
-			secondInc := math.MaxInt
-			for i := 0; i < len(tour); i++ {
-				a := tour[i]
-				b := tour[(i+1)%len(tour)]
-				inc := D[a][v] + D[v][b] - D[a][b]
-				if inc < secondInc && i+1 != bestPos {
-					secondInc = inc
-				}
-			}
-			if secondInc == math.MaxInt {
-				secondInc = bestInc
-			}
-			bestTot := bestInc + nodes[v].Cost
-			secondTot := secondInc + nodes[v].Cost
-			regret := secondTot - bestTot
-			score := alpha*float64(regret) - beta*float64(bestTot)
-			cands = append(cands, cand{v, bestTot, secondTot, bestPos, score})
-		}
-		sort.Slice(cands, func(a, b int) bool { return cands[a].score > cands[b].score })
-		ch := cands[0]
-		selected[ch.node] = true
-		tour = insertAt(tour, ch.bestPos, ch.node)
+// RegretRepair extends a partial tour with weighted 2-regret insertion until it holds K nodes.
+// selected is updated in-place.
+func RegretRepair(inst *Instance, tour []int, selected []bool) []int {
+	c := newRegretCache(inst, tour, selected)
+	for len(c.tour) < inst.K {
+		ch := c.top()
+		c.insert(ch.node, ch.bestPos)
 	}
-	
-
-	return tour, selected
+	return c.tour
 }
 
 // LOCAL SEARCH moves and deltas
@@ -340,6 +374,25 @@ func delta2Opt(dist [][]int, nodes []Node, tour []int, i int, j int) int {
 	return deltaLen
 }
 
+// apply a node swap between positions i and j
+func applySwap(tour []int, i int, j int) {
+	tour[i], tour[j] = tour[j], tour[i]
+}
+
+// apply 2-opt for i<j: reverse segment i+1..j
+func apply2Opt(tour []int, i int, j int) {
+	for a, b := i+1, j; a < b; a, b = a+1, b-1 {
+		tour[a], tour[b] = tour[b], tour[a]
+	}
+}
+
+// apply inter exchange: replace tour[pos] with unselected node u
+func applyReplace(tour []int, inSel []bool, pos int, u int) {
+	inSel[tour[pos]] = false
+	inSel[u] = true
+	tour[pos] = u
+}
+
 // GREEDY local search: browse neighbors in randomized order, stop at first improving move
 // returns whether an improvement was applied (true) and updates tour/inSel in-place
 func LocalSearchGreedy(inst *Instance, tour []int, inSel []bool, intraMode string, rnd *rand.Rand, evalLimit int) (bool, int, int) {
@@ -608,63 +661,135 @@ func RunLocalSearch(inst *Instance, tour []int, inSel []bool, mode string, intra
 	return tourCopy, inCopy, evalsTotal, improvements
 }
 
-func runMethods(inst *Instance, runs int, seed int64, outPath string) error {
-	rnd := rand.New(rand.NewSource(seed))
-	outFile, err := os.Create(outPath)
+// RunResult is what a method returns for a single run
+type RunResult struct {
+	Tour         []int
+	Evals        int
+	Improvements int
+	Restarts     int // number of local search restarts (0 for plain local search)
+	Iters        int // main loop iterations of a metaheuristic (0 for plain local search)
+	Trace        []TracePoint
+}
+
+// TracePoint is one sample of a method's search trajectory.
+// Value is method specific (e.g. temperature for simulated annealing); Label names it
+// when a method traces several values (e.g. ALNS operator statistics).
+type TracePoint struct {
+	Iter      int
+	Objective int
+	Best      int
+	Value     float64
+	Label     string
+}
+
+// Method is a named improvement procedure applied to a starting solution
+type Method struct {
+	Name      string
+	StartType string // "random" or "greedy"
+	Improve   func(inst *Instance, tour []int, inSel []bool, rnd *rand.Rand) RunResult
+	Weights   *RegretWeights // regret weights of the run (nil = those of the instance)
+	Options   string         // fingerprint of the options that built the method (see REPRODUCIBILITY)
+}
+
+// LocalSearchMethods returns the plain local search variants for every start type
+// (random and greedy give the 8 original methods)
+func LocalSearchMethods(startTypes []string) []Method {
+	var methods []Method
+	for _, mode := range []string{"steepest", "greedy"} {
+		for _, intraMode := range []string{"nodes", "edges"} {
+			for _, startType := range startTypes {
+				methods = append(methods, Method{
+					Name:      fmt.Sprintf("%s_intra:%s_start:%s", mode, intraMode, startType),
+					StartType: startType,
+					Improve: func(inst *Instance, tour []int, inSel []bool, rnd *rand.Rand) RunResult {
+						finalTour, _, evals, imps := RunLocalSearch(inst, tour, inSel, mode, intraMode, rnd)
+						return RunResult{Tour: finalTour, Evals: evals, Improvements: imps}
+					},
+				})
+			}
+		}
+	}
+	return methods
+}
+
+// StartSolution builds the starting solution for a given run
+func StartSolution(inst *Instance, startType string, run int, rnd *rand.Rand) ([]int, []bool) {
+	if startType == "random" {
+		return RandomStart(inst, rnd)
+	}
+	// deterministic constructions: use starting node = run % N (to emulate using different starting nodes)
+	construct, ok := Constructions[startType]
+	if !ok {
+		construct = GreedyRegretStart
+	}
+	return construct(inst, run%inst.N)
+}
+
+// runMethods runs every method runs times on `workers` goroutines (see runJobs);
+// bound is a lower bound used for the gap column (0 = unknown)
+func runMethods(inst *Instance, methods []Method, runs int, seed int64, workers int, outPath string, tracePath string, bound int, resume bool) error {
+	// completed jobs of an interrupted run, see CHECKPOINTING
+	done := make([]bool, len(methods)*runs)
+	outFile, err := openCSV(outPath, resultHeader, resume, completedJobs(methods, runs, seed, done))
 	if err != nil {
 		return err
 	}
 	defer outFile.Close()
 	w := csv.NewWriter(outFile)
 	defer w.Flush()
+	if resume {
+		skipped := 0
+		for _, d := range done {
+			if d {
+				skipped++
+			}
+		}
+		fmt.Printf("Resuming %s: %d of %d runs already done\n", outPath, skipped, len(done))
+	}
 
-	// write header
-	if err := w.Write([]string{"method", "run", "objective", "tour_length", "selected_costs", "evaluations", "improvements", "final_selected", "seed", "duration_ms"}); err != nil {
-		return err
+	// optional trajectory output
+	var tw *csv.Writer
+	if tracePath != "" {
+		traceFile, err := openCSV(tracePath, traceHeader, resume, tracedJobs(methods, runs, done))
+		if err != nil {
+			return err
+		}
+		defer traceFile.Close()
+		tw = csv.NewWriter(traceFile)
+		defer tw.Flush()
 	}
 
-	methods := []struct {
-		mode      string // "steepest" or "greedy"
-		intraMode string // "nodes" or "edges"
-		startType string // "random" or "greedy"
-	}{
-		{"steepest", "nodes", "random"},
-		{"steepest", "nodes", "greedy"},
-		{"steepest", "edges", "random"},
-		{"steepest", "edges", "greedy"},
-		{"greedy", "nodes", "random"},
-		{"greedy", "nodes", "greedy"},
-		{"greedy", "edges", "random"},
-		{"greedy", "edges", "greedy"},
-	}
-	for _, m := range methods {
-		methodName := fmt.Sprintf("%s_intra:%s_start:%s", m.mode, m.intraMode, m.startType)
-		fmt.Printf("Running method %s with %d runs...\n", methodName, runs)
+	quit := make(chan struct{})
+	defer close(quit)
+	results := runJobs(inst, methods, runs, seed, workers, done, quit)
+	for mi, m := range methods {
 		for run := 0; run < runs; run++ {
-			// create a per-run RNG so results are reproducible
-			runSeed := int64(rnd.Int63())
-			runRnd := rand.New(rand.NewSource(runSeed))
-
-			var tour []int
-			var inSel []bool
-			if m.startType == "random" {
-				tour0, in0 := RandomStart(inst, runRnd)
-				tour = tour0
-				inSel = in0
-			} else {
-				// greedy start: use starting node = run % N (to emulate using different starting nodes)
-				startNode := run % inst.N
-				tour0, in0 := GreedyRegretStart(inst, startNode)
-				tour = tour0
-				inSel = in0
+			if done[mi*runs+run] {
+				continue
 			}
-
-			// run local search
-			start := time.Now()
-			finalTour, _, evals, imps := RunLocalSearch(inst, tour, inSel, m.mode, m.intraMode, runRnd)
-			elapsed := time.Since(start)
+			// every run has its own RNG, see RunSeed
+			runSeed := RunSeed(seed, m, run)
+			jr := <-results[mi*runs+run]
+			res, elapsed := jr.res, jr.elapsed
 			elapsedS := strconv.FormatFloat(elapsed.Seconds(), 'f', 6, 64)
+			if tw != nil {
+				for _, tp := range res.Trace {
+					if err := tw.Write([]string{
+						m.Name,
+						strconv.Itoa(run),
+						strconv.Itoa(tp.Iter),
+						strconv.Itoa(tp.Objective),
+						strconv.Itoa(tp.Best),
+						strconv.FormatFloat(tp.Value, 'g', 6, 64),
+						tp.Label,
+					}); err != nil {
+						return err
+					}
+				}
+				tw.Flush()
+			}
 			// compute objective values for output
+			finalTour := res.Tour
 			tLen := TourLength(inst.Dist, finalTour)
 			sCost := SelectedCosts(inst.Nodes, finalTour)
 			obj := tLen + sCost
@@ -675,26 +800,35 @@ func runMethods(inst *Instance, runs int, seed int64, outPath string) error {
 				strSel[i] = strconv.Itoa(finalTour[i])
 			}
 			if err := w.Write([]string{
-				methodName,
+				m.Name,
 				strconv.Itoa(run),
 				strconv.Itoa(obj),
 				strconv.Itoa(tLen),
 				strconv.Itoa(sCost),
-				strconv.Itoa(evals),
-				strconv.Itoa(imps),
+				strconv.Itoa(res.Evals),
+				strconv.Itoa(res.Improvements),
 				strings.Join(strSel, ";"),
 				strconv.FormatInt(runSeed, 10),
 				elapsedS,
+				strconv.Itoa(res.Restarts),
+				gapColumn(obj, bound),
+				strconv.Itoa(res.Iters),
+				m.Options,
 			}); err != nil {
 				return err
 			}
-			// periodically flush
-			if run%50 == 0 {
-				w.Flush()
+			// the row marks the job as done, so it follows the trace and is flushed at once
+			w.Flush()
+			if err := w.Error(); err != nil {
+				return err
 			}
 		}
 	}
 	w.Flush()
+	if tw != nil {
+		tw.Flush()
+		return tw.Error()
+	}
 	return nil
 }
 
@@ -703,7 +837,32 @@ func main() {
 	outPath := flag.String("out", "result.csv", "output CSV results path")
 	runs := flag.Int("runs", 200, "number of runs per method")
 	seed := flag.Int64("seed", time.Now().UnixNano(), "random seed")
+	opts := DefaultOptions()
+	opts.Register(flag.CommandLine)
+	exact := flag.Bool("exact", false, "solve the (small, see -sub) instance exactly, print the gaps of the constructions and local searches, and exit")
+	mipOut := flag.String("mip", "", "write the MIP model (LP format) of the (see -sub) instance to this path and exit")
+	mipSol := flag.String("mipsol", "", "read a CBC/HiGHS solution of the -mip model, print its objective and exit")
+	tuneSpec := flag.String("tune", "", "tune flags of -algo instead of running it, e.g. \"accept=better|sa;temp=10:1000\" (name=v1|v2|... categorical, name=lo:hi range)")
+	tuneMode := flag.String("tunemode", "race", "tuner: grid, random or race (random configurations raced with Friedman/Wilcoxon eliminations)")
+	tuneIn := flag.String("tunein", "", "comma-separated tuning instances (default: -in)")
+	tuneConfigs := flag.Int("tuneconfigs", 20, "number of sampled configurations for -tunemode random and race")
+	tuneGrid := flag.Int("tunegrid", 3, "points per numeric range for -tunemode grid")
+	tuneBudget := flag.Duration("tunebudget", 10*time.Minute, "total tuning time; runs per configuration are capped by -runs per instance")
+	replay := flag.String("replay", "", "replay one run in isolation: method,run,seed[,iterations[,options]] with seed = -seed of the experiment, iterations and options from its CSV; other flags as in the experiment")
+	resume := flag.Bool("resume", false, "continue an interrupted run: keep the completed runs in -out (and -trace) and append the missing ones; needs the same -seed and flags")
+	tracePath := flag.String("trace", "", "optional CSV path for search trajectories (SA temperature, ACO branching factor, reactive GRASP alpha, GLS augmented objective, ALNS operator success rates and weights, ...)")
 	flag.Parse()
+
+	if flag.NArg() > 0 {
+		if flag.NArg() != 3 || flag.Arg(0) != "experiment" || flag.Arg(1) != "run" {
+			log.Fatalf("Unknown command %q. Usage: ./app experiment run config.json", strings.Join(flag.Args(), " "))
+		}
+		if err := RunExperiment(flag.Arg(2), *resume); err != nil {
+			log.Fatalf("Experiment failed: %v", err)
+		}
+		return
+	}
+
 	if *inPath == "" || *outPath == "" {
 		log.Fatalf("Please provide -in and -out paths. Example: ./app -in instance.csv -out results.csv")
 	}
@@ -713,8 +872,95 @@ func main() {
 	}
 	fmt.Printf("Read instance with N=%d nodes, selecting K=%d nodes\n", inst.N, inst.K)
 
+	if opts.Sub > 0 {
+		if opts.Sub > inst.N {
+			log.Fatalf("-sub %d exceeds N=%d", opts.Sub, inst.N)
+		}
+		inst = SubInstance(inst, opts.Sub)
+		fmt.Printf("Using the first %d nodes, selecting K=%d nodes\n", inst.N, inst.K)
+	}
+	if *mipOut != "" {
+		if err := WriteLP(inst, *mipOut); err != nil {
+			log.Fatalf("Failed to write MIP model: %v", err)
+		}
+		fmt.Printf("MIP model written to %s\n", *mipOut)
+		return
+	}
+	if *mipSol != "" {
+		tour, err := ReadMIPSolution(inst, *mipSol)
+		if err != nil {
+			log.Fatalf("Failed to read MIP solution: %v", err)
+		}
+		fmt.Printf("MIP solution: objective %d (length %d, costs %d), tour %v\n",
+			Objective(inst, tour), TourLength(inst.Dist, tour), SelectedCosts(inst.Nodes, tour), tour)
+		return
+	}
+	if *exact {
+		if err := ExactGapReport(inst, *runs, rand.New(rand.NewSource(*seed))); err != nil {
+			log.Fatalf("Exact solver failed: %v", err)
+		}
+		return
+	}
+
+	if *tuneSpec != "" {
+		space, err := ParseTuneSpace(*tuneSpec, opts)
+		if err != nil {
+			log.Fatalf("Invalid -tune: %v", err)
+		}
+		paths := []string{*inPath}
+		if *tuneIn != "" {
+			paths = strings.Split(*tuneIn, ",")
+		}
+		var insts []*Instance
+		for _, p := range paths {
+			ti, err := ReadInstanceCSV(p)
+			if err != nil {
+				log.Fatalf("Failed to read tuning instance: %v", err)
+			}
+			if opts.Sub > 0 {
+				ti = SubInstance(ti, min(opts.Sub, ti.N))
+			}
+			insts = append(insts, ti)
+		}
+		err = Tune(insts, paths, space, opts, TuneOptions{
+			Mode:    *tuneMode,
+			Configs: *tuneConfigs,
+			Grid:    *tuneGrid,
+			Runs:    *runs,
+			Budget:  *tuneBudget,
+			Seed:    *seed,
+			OutPath: *outPath,
+		})
+		if err != nil {
+			log.Fatalf("Tuning failed: %v", err)
+		}
+		return
+	}
+
+	if *replay != "" {
+		if err := Replay(inst, *replay, opts); err != nil {
+			log.Fatalf("Replay failed: %v", err)
+		}
+		return
+	}
 
-	err = runMethods(inst, *runs, *seed, *outPath)
+	methods, err := BuildMethods(opts)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	fmt.Printf("Seed: %d\n", *seed)
+
+	bound := 0
+	if opts.LBIters > 0 {
+		ub, _ := GreedyRegretStart(inst, 0)
+		bound = LagrangianBound(inst, Objective(inst, ub), opts.LBIters)
+		fmt.Printf("Lagrangian lower bound: %d\n", bound)
+	}
+
+	if opts.Workers > runtime.NumCPU() {
+		fmt.Printf("Warning: %d workers on %d CPUs, durations and time-budgeted results suffer\n", opts.Workers, runtime.NumCPU())
+	}
+	err = runMethods(inst, methods, *runs, *seed, opts.Workers, *outPath, *tracePath, bound, *resume)
 	if err != nil {
 		log.Fatalf("runMethods failed: %v", err)
 	}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// MIP MODEL EXPORT
+//
+// Directed formulation written in CPLEX LP format (read by CBC, HiGHS, GLPK, ...):
+//   y_i   node i is selected                         (binary)
+//   x_i_j arc i->j is used                           (binary, i != j)
+//   r_i   node i is the root of the cycle            (binary)
+//   u_i   position of node i after the root (MTZ)    (continuous in [0, K-1])
+// min  sum d_ij x_i_j + sum cost_i y_i
+// s.t. sum_j x_i_j = y_i, sum_j x_j_i = y_i          (one arc out / in per selected node)
+//      sum y_i = K, sum r_i = 1, r_i <= y_i
+//      u_j - u_i - K x_i_j + K r_j >= 1 - K          (MTZ subtour elimination, relaxed at the root)
+
+// WriteLP writes the MIP model of the instance to path
+func WriteLP(inst *Instance, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	N, K := inst.N, inst.K
+
+	fmt.Fprintf(w, "\\ selection-TSP: N=%d, K=%d\n", N, K)
+	fmt.Fprintln(w, "Minimize")
+	fmt.Fprint(w, " obj:")
+	for i := 0; i < N; i++ {
+		fmt.Fprintf(w, " + %d y_%d", inst.Nodes[i].Cost, i)
+		for j := 0; j < N; j++ {
+			if i != j {
+				fmt.Fprintf(w, " + %d x_%d_%d", inst.Dist[i][j], i, j)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w, "Subject To")
+	for i := 0; i < N; i++ {
+		fmt.Fprintf(w, " out_%d:", i)
+		for j := 0; j < N; j++ {
+			if i != j {
+				fmt.Fprintf(w, " + x_%d_%d", i, j)
+			}
+		}
+		fmt.Fprintf(w, " - y_%d = 0\n", i)
+		fmt.Fprintf(w, " in_%d:", i)
+		for j := 0; j < N; j++ {
+			if i != j {
+				fmt.Fprintf(w, " + x_%d_%d", j, i)
+			}
+		}
+		fmt.Fprintf(w, " - y_%d = 0\n", i)
+		fmt.Fprintf(w, " root_%d: r_%d - y_%d <= 0\n", i, i, i)
+	}
+	fmt.Fprint(w, " card:")
+	for i := 0; i < N; i++ {
+		fmt.Fprintf(w, " + y_%d", i)
+	}
+	fmt.Fprintf(w, " = %d\n", K)
+	fmt.Fprint(w, " oneroot:")
+	for i := 0; i < N; i++ {
+		fmt.Fprintf(w, " + r_%d", i)
+	}
+	fmt.Fprintln(w, " = 1")
+	for i := 0; i < N; i++ {
+		for j := 0; j < N; j++ {
+			if i != j {
+				fmt.Fprintf(w, " mtz_%d_%d: u_%d - u_%d - %d x_%d_%d + %d r_%d >= %d\n", i, j, j, i, K, i, j, K, j, 1-K)
+			}
+		}
+	}
+
+	fmt.Fprintln(w, "Bounds")
+	for i := 0; i < N; i++ {
+		fmt.Fprintf(w, " 0 <= u_%d <= %d\n", i, K-1)
+	}
+	fmt.Fprintln(w, "Binary")
+	for i := 0; i < N; i++ {
+		fmt.Fprintf(w, " y_%d r_%d\n", i, i)
+		for j := 0; j < N; j++ {
+			if i != j {
+				fmt.Fprintf(w, " x_%d_%d\n", i, j)
+			}
+		}
+	}
+	fmt.Fprintln(w, "End")
+	return w.Flush()
+}
+
+// ReadMIPSolution reads the arcs x_i_j with value > 0.5 from a CBC or HiGHS solution
+// file (any line where a variable name is followed by its value) and returns the tour
+func ReadMIPSolution(inst *Instance, path string) ([]int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	succ := make([]int, inst.N)
+	for i := range succ {
+		succ[i] = -1
+	}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		for k := 0; k+1 < len(fields); k++ {
+			parts := strings.Split(fields[k], "_")
+			if len(parts) != 3 || parts[0] != "x" {
+				continue
+			}
+			i, err1 := strconv.Atoi(parts[1])
+			j, err2 := strconv.Atoi(parts[2])
+			val, err3 := strconv.ParseFloat(fields[k+1], 64)
+			if err1 != nil || err2 != nil || err3 != nil || i < 0 || j < 0 || i >= inst.N || j >= inst.N {
+				continue
+			}
+			if val > 0.5 {
+				if succ[i] >= 0 {
+					return nil, fmt.Errorf("node %d has two outgoing arcs", i)
+				}
+				succ[i] = j
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	start := -1
+	for i, s := range succ {
+		if s >= 0 {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("no arcs with value 1 in %s", path)
+	}
+	tour := []int{start}
+	for v := succ[start]; v != start; v = succ[v] {
+		if v < 0 || len(tour) > inst.N {
+			return nil, fmt.Errorf("arcs do not form a cycle")
+		}
+		tour = append(tour, v)
+	}
+	if err := ValidateSolution(inst, tour, selectionOf(inst.N, tour)); err != nil {
+		return nil, err
+	}
+	return tour, nil
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// lpRow is a linear expression of an LP file with its relation ("" for the objective)
+type lpRow struct {
+	name  string
+	terms map[string]float64
+	op    string
+	rhs   float64
+}
+
+// lpModel is the part of the LP format written by WriteLP
+type lpModel struct {
+	obj    lpRow
+	rows   []lpRow
+	bounds map[string][2]float64
+	binary []string
+}
+
+// parseTerms parses "[+|-] [coef] var ..." into coefficients
+func parseTerms(tokens []string) (map[string]float64, error) {
+	terms := map[string]float64{}
+	for k := 0; k < len(tokens); {
+		sign := 1.0
+		if tokens[k] == "+" || tokens[k] == "-" {
+			if tokens[k] == "-" {
+				sign = -1
+			}
+			k++
+		}
+		coef := 1.0
+		if k < len(tokens) {
+			if c, err := strconv.ParseFloat(tokens[k], 64); err == nil {
+				coef = c
+				k++
+			}
+		}
+		if k >= len(tokens) {
+			return nil, fmt.Errorf("term without variable in %v", tokens)
+		}
+		terms[tokens[k]] += sign * coef
+		k++
+	}
+	return terms, nil
+}
+
+func readLP(path string) (*lpModel, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	m := &lpModel{obj: lpRow{terms: map[string]float64{}}, bounds: map[string][2]float64{}}
+	section := ""
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 1<<20), 1<<20)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch line {
+		case "Minimize", "Subject To", "Bounds", "Binary", "End":
+			section = line
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "\\") {
+			continue
+		}
+		fields := strings.Fields(line)
+		switch section {
+		case "Minimize":
+			if strings.HasSuffix(fields[0], ":") {
+				fields = fields[1:]
+			}
+			terms, err := parseTerms(fields)
+			if err != nil {
+				return nil, err
+			}
+			for v, c := range terms {
+				m.obj.terms[v] += c
+			}
+		case "Subject To":
+			n := len(fields)
+			terms, err := parseTerms(fields[1 : n-2])
+			if err != nil {
+				return nil, err
+			}
+			rhs, err := strconv.ParseFloat(fields[n-1], 64)
+			if err != nil {
+				return nil, err
+			}
+			m.rows = append(m.rows, lpRow{strings.TrimSuffix(fields[0], ":"), terms, fields[n-2], rhs})
+		case "Bounds":
+			lo, err1 := strconv.ParseFloat(fields[0], 64)
+			hi, err2 := strconv.ParseFloat(fields[4], 64)
+			if len(fields) != 5 || err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("unexpected bound %q", line)
+			}
+			m.bounds[fields[2]] = [2]float64{lo, hi}
+		case "Binary":
+			m.binary = append(m.binary, fields...)
+		}
+	}
+	return m, sc.Err()
+}
+
+func (r lpRow) value(vals map[string]float64) float64 {
+	s := 0.0
+	for v, c := range r.terms {
+		s += c * vals[v]
+	}
+	return s
+}
+
+// violated returns the first constraint or bound the assignment breaks, "" if none
+func (m *lpModel) violated(vals map[string]float64) string {
+	for _, r := range m.rows {
+		lhs := r.value(vals)
+		ok := r.op == "=" && lhs == r.rhs || r.op == "<=" && lhs <= r.rhs || r.op == ">=" && lhs >= r.rhs
+		if !ok {
+			return fmt.Sprintf("%s: %g %s %g", r.name, lhs, r.op, r.rhs)
+		}
+	}
+	for v, b := range m.bounds {
+		if vals[v] < b[0] || vals[v] > b[1] {
+			return fmt.Sprintf("bound of %s: %g not in [%g, %g]", v, vals[v], b[0], b[1])
+		}
+	}
+	for _, v := range m.binary {
+		if vals[v] != 0 && vals[v] != 1 {
+			return fmt.Sprintf("%s = %g is not binary", v, vals[v])
+		}
+	}
+	return ""
+}
+
+// lpAssignment encodes a tour rooted at its first node in the variables of WriteLP
+func lpAssignment(tour []int) map[string]float64 {
+	vals := map[string]float64{fmt.Sprintf("r_%d", tour[0]): 1}
+	for pos, v := range tour {
+		vals[fmt.Sprintf("y_%d", v)] = 1
+		vals[fmt.Sprintf("u_%d", v)] = float64(pos)
+		vals[fmt.Sprintf("x_%d_%d", v, tour[(pos+1)%len(tour)])] = 1
+	}
+	return vals
+}
+
+// TestWriteLPFeasibleTours exports a small instance and checks that every K-node cycle
+// satisfies the model with its objective, so the model optimum is the SolveExact optimum
+func TestWriteLPFeasibleTours(t *testing.T) {
+	inst := SubInstance(readTestInstance(t, "../TSPA.csv"), 7)
+	path := filepath.Join(t.TempDir(), "model.lp")
+	if err := WriteLP(inst, path); err != nil {
+		t.Fatal(err)
+	}
+	model, err := readLP(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	best, cycles := -1, 0
+	var extend func(tour []int, used []bool)
+	extend = func(tour []int, used []bool) {
+		if len(tour) == inst.K {
+			cycles++
+			vals := lpAssignment(tour)
+			if v := model.violated(vals); v != "" {
+				t.Fatalf("tour %v violates %s", tour, v)
+			}
+			obj := Objective(inst, tour)
+			if got := model.obj.value(vals); got != float64(obj) {
+				t.Fatalf("tour %v: model objective %g, Objective %d", tour, got, obj)
+			}
+			if best < 0 || obj < best {
+				best = obj
+			}
+			return
+		}
+		for v := 0; v < inst.N; v++ {
+			if !used[v] {
+				used[v] = true
+				extend(append(tour, v), used)
+				used[v] = false
+			}
+		}
+	}
+	extend(nil, make([]bool, inst.N))
+
+	_, exact, err := SolveExact(inst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if best != exact {
+		t.Errorf("best of %d feasible model tours is %d, SolveExact gives %d", cycles, best, exact)
+	}
+}
+
+// TestWriteLPRejectsSubtours checks that two disjoint cycles covering K nodes violate an
+// MTZ row for every integer choice of positions, while all other rows hold
+func TestWriteLPRejectsSubtours(t *testing.T) {
+	inst := SubInstance(readTestInstance(t, "../TSPA.csv"), 10)
+	path := filepath.Join(t.TempDir(), "model.lp")
+	if err := WriteLP(inst, path); err != nil {
+		t.Fatal(err)
+	}
+	model, err := readLP(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cycles := [][]int{{0, 1}, {2, 3, 4}}
+	vals := map[string]float64{"r_0": 1}
+	var nodes []int
+	for _, c := range cycles {
+		for pos, v := range c {
+			vals[fmt.Sprintf("y_%d", v)] = 1
+			vals[fmt.Sprintf("x_%d_%d", v, c[(pos+1)%len(c)])] = 1
+			nodes = append(nodes, v)
+		}
+	}
+	if len(nodes) != inst.K {
+		t.Fatalf("the cycles cover %d nodes, K = %d", len(nodes), inst.K)
+	}
+	// every position vector in {0..K-1}^K
+	u := make([]int, len(nodes))
+	for {
+		for i, v := range nodes {
+			vals[fmt.Sprintf("u_%d", v)] = float64(u[i])
+		}
+		if v := model.violated(vals); !strings.HasPrefix(v, "mtz_") {
+			t.Fatalf("subtours %v with positions %v: violated row %q, want an MTZ row", cycles, u, v)
+		}
+		i := 0
+		for i < len(u) && u[i] == inst.K-1 {
+			u[i] = 0
+			i++
+		}
+		if i == len(u) {
+			break
+		}
+		u[i]++
+	}
+}
+
+// TestReadMIPSolution writes the optimal tour of a small instance as CBC and HiGHS
+// solution files (with every arc, rounding noise included) and reads it back
+func TestReadMIPSolution(t *testing.T) {
+	inst := SubInstance(readTestInstance(t, "../TSPB.csv"), 8)
+	tour, obj, err := SolveExact(inst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	succ := map[int]int{}
+	for pos, v := range tour {
+		succ[v] = tour[(pos+1)%len(tour)]
+	}
+	arcValue := func(i, j int) string {
+		if s, ok := succ[i]; ok && s == j {
+			return "0.99999999"
+		}
+		return "1e-09"
+	}
+
+	var cbc, highs strings.Builder
+	fmt.Fprintf(&cbc, "Optimal - objective value %d.00000000\n", obj)
+	fmt.Fprintf(&highs, "Model status\nOptimal\n\n# Primal solution values\nFeasible\nObjective %d\n# Columns %d\n", obj, inst.N*inst.N)
+	col := 0
+	for i := 0; i < inst.N; i++ {
+		for j := 0; j < inst.N; j++ {
+			if i == j {
+				continue
+			}
+			fmt.Fprintf(&cbc, "%7d x_%d_%d %24s %24s\n", col, i, j, arcValue(i, j), "0")
+			fmt.Fprintf(&highs, "x_%d_%d %s\n", i, j, arcValue(i, j))
+			col++
+		}
+		y := "0"
+		if _, ok := succ[i]; ok {
+			y = "1"
+		}
+		fmt.Fprintf(&cbc, "%7d y_%d %24s %24s\n", col, i, y, "0")
+		col++
+	}
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{"cbc.sol": cbc.String(), "highs.sol": highs.String()} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ReadMIPSolution(inst, path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(got) != len(tour) {
+			t.Fatalf("%s: tour %v, SolveExact gives %v", name, got, tour)
+		}
+		for pos, v := range got {
+			if succ[v] != got[(pos+1)%len(got)] {
+				t.Fatalf("%s: tour %v, SolveExact gives %v", name, got, tour)
+			}
+		}
+		if Objective(inst, got) != obj {
+			t.Errorf("%s: objective %d, SolveExact gives %d", name, Objective(inst, got), obj)
+		}
+	}
+}
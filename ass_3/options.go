@@ -0,0 +1,391 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// METHOD OPTIONS
+//
+// Options holds the settings of a run that have a command-line flag of the same name:
+// everything BuildMethods needs to build the methods of -algo, plus the instance
+// preparation (-sub, -lbiters) and the parallelism (-workers). main registers them on
+// the command line. The tuner and the experiment runner describe configurations as
+// "name=value" settings of these flags and apply them with With to a copy of their base
+// options, so configurations share no state with each other or with the process flags.
+
+// Options configures BuildMethods and the runs of an instance
+type Options struct {
+	Starts string // start types of -algo ls/construct
+	Algo   string
+	Mode   string // local search inside metaheuristics
+	Intra  string
+	Budget time.Duration
+	Iters  int // exact iteration count instead of Budget (0 = off)
+
+	// ILS and path relinking
+	Perturb  string
+	Strength int
+	Accept   string
+	Temp     float64
+	Cooling  float64
+	Elite    int
+	Relink   int
+	PRLS     int
+
+	// LNS
+	Destroy string
+	LNSLS   bool
+
+	// SA
+	Schedule string
+	SATemp   float64
+	SAAlpha  float64
+	SABeta   float64
+	SAEpoch  int
+
+	// tabu search
+	Tenure     int
+	TenureMode string
+
+	// HEA
+	Pop   int
+	HEALS bool
+	XOver string
+
+	// ACO
+	Ants     int
+	ACOAlpha float64
+	ACOBeta  float64
+	Rho      float64
+
+	// GRASP
+	RCL      string
+	RCLSize  int
+	RCLAlpha float64
+
+	// VND/VNS and GLS
+	NBH  string
+	KMax int
+	GLSA float64
+
+	// weighted regret constructions
+	WAlpha float64
+	WBeta  float64
+
+	Sub     int
+	LBIters int
+	Workers int
+}
+
+// DefaultOptions returns the defaults of the command-line flags
+func DefaultOptions() Options {
+	return Options{
+		Starts:     "random,greedy",
+		Algo:       "ls",
+		Mode:       "steepest",
+		Intra:      "edges",
+		Budget:     time.Second,
+		Perturb:    "double-bridge",
+		Strength:   10,
+		Accept:     "better",
+		Temp:       100,
+		Cooling:    0.99,
+		Relink:     20,
+		PRLS:       5,
+		Destroy:    "random",
+		LNSLS:      true,
+		Schedule:   "geometric",
+		SAAlpha:    0.95,
+		SABeta:     1e-3,
+		SAEpoch:    1000,
+		TenureMode: "fixed",
+		Pop:        20,
+		HEALS:      true,
+		XOver:      "regret",
+		Ants:       20,
+		ACOAlpha:   1,
+		ACOBeta:    3,
+		Rho:        0.02,
+		RCL:        "alpha",
+		RCLSize:    3,
+		RCLAlpha:   0.1,
+		NBH:        "2opt,replace,oropt,swap",
+		KMax:       10,
+		GLSA:       0.3,
+		WAlpha:     1,
+		WBeta:      1,
+		LBIters:    1000,
+		Workers:    1,
+	}
+}
+
+// Register defines a flag for every option on fs, with the current values of o as defaults
+func (o *Options) Register(fs *flag.FlagSet) {
+	fs.StringVar(&o.Starts, "starts", o.Starts, "start types for -algo ls/construct: random, greedy (weighted regret), regret, nn_end, nn_anywhere, greedy_cycle, hilbert, hull, sr_<score|medoids|knapsack>_<nn|mst> (select first, route second)")
+	fs.StringVar(&o.Algo, "algo", o.Algo, "algorithm: ls (all local search variants), construct (start solutions only), ils, lns, sa, tabu, hea, aco, grasp, vnd, vns or gls")
+	fs.StringVar(&o.Mode, "mode", o.Mode, "local search used inside metaheuristics: steepest or greedy")
+	fs.StringVar(&o.Intra, "intra", o.Intra, "intra-route moves used inside metaheuristics: nodes or edges")
+	fs.DurationVar(&o.Budget, "budget", o.Budget, "time budget per run for metaheuristics")
+	fs.StringVar(&o.Perturb, "perturb", o.Perturb, "ILS perturbation: double-bridge, reverse or replace")
+	fs.IntVar(&o.Strength, "strength", o.Strength, "ILS number of nodes replaced by the replace perturbation")
+	fs.StringVar(&o.Accept, "accept", o.Accept, "ILS acceptance: better, equal or sa")
+	fs.Float64Var(&o.Temp, "temp", o.Temp, "ILS initial temperature for sa acceptance")
+	fs.Float64Var(&o.Cooling, "cooling", o.Cooling, "ILS temperature multiplier per iteration for sa acceptance")
+	fs.IntVar(&o.Elite, "elite", o.Elite, "ILS elite pool size for path relinking (0 = off)")
+	fs.IntVar(&o.Relink, "relink", o.Relink, "ILS iterations between path relinking steps")
+	fs.IntVar(&o.PRLS, "prls", o.PRLS, "path relinking: local search on every n-th intermediate solution (0 = never)")
+	fs.StringVar(&o.Destroy, "destroy", o.Destroy, "LNS destroy operator: random, worst, related, segment or adaptive (ALNS)")
+	fs.BoolVar(&o.LNSLS, "lnsls", o.LNSLS, "LNS: run local search after every repair")
+	fs.StringVar(&o.Schedule, "schedule", o.Schedule, "SA cooling schedule: geometric, lundy-mees or reheat")
+	fs.Float64Var(&o.SATemp, "satemp", o.SATemp, "SA initial temperature (0 = calibrate from sampled deltas)")
+	fs.Float64Var(&o.SAAlpha, "saalpha", o.SAAlpha, "SA geometric cooling factor per epoch")
+	fs.Float64Var(&o.SABeta, "sabeta", o.SABeta, "SA Lundy-Mees cooling parameter")
+	fs.IntVar(&o.SAEpoch, "saepoch", o.SAEpoch, "SA number of moves between temperature updates")
+	fs.IntVar(&o.Tenure, "tenure", o.Tenure, "tabu tenure (0 = K/4)")
+	fs.StringVar(&o.TenureMode, "tenuremode", o.TenureMode, "tabu tenure: fixed, random or reactive")
+	fs.IntVar(&o.Pop, "pop", o.Pop, "HEA elite population size")
+	fs.BoolVar(&o.HEALS, "heals", o.HEALS, "HEA: run local search on every offspring")
+	fs.StringVar(&o.XOver, "xover", o.XOver, "HEA recombination: regret, random-fill, erx, ox, gpx or pr (path relinking)")
+	fs.IntVar(&o.Ants, "ants", o.Ants, "ACO number of ants per iteration")
+	fs.Float64Var(&o.ACOAlpha, "acoalpha", o.ACOAlpha, "ACO pheromone exponent")
+	fs.Float64Var(&o.ACOBeta, "acobeta", o.ACOBeta, "ACO visibility exponent")
+	fs.Float64Var(&o.Rho, "rho", o.Rho, "ACO pheromone evaporation rate")
+	fs.StringVar(&o.RCL, "rcl", o.RCL, "GRASP restricted candidate list: cardinality, alpha or reactive")
+	fs.IntVar(&o.RCLSize, "rclsize", o.RCLSize, "GRASP number of candidates kept by the cardinality RCL")
+	fs.Float64Var(&o.RCLAlpha, "rclalpha", o.RCLAlpha, "GRASP score threshold of the alpha RCL")
+	fs.StringVar(&o.NBH, "nbh", o.NBH, "VND/VNS neighbourhood order (swap, 2opt, replace, oropt)")
+	fs.IntVar(&o.KMax, "kmax", o.KMax, "VNS largest number of random shaking moves")
+	fs.Float64Var(&o.GLSA, "glsa", o.GLSA, "GLS lambda scaling (lambda = a * objective / 2K)")
+	fs.IntVar(&o.Sub, "sub", o.Sub, "use only the first n nodes of the instance (0 = all)")
+	fs.IntVar(&o.LBIters, "lbiters", o.LBIters, "subgradient iterations of the Lagrangian lower bound (0 = no bound, empty gap column)")
+	fs.Float64Var(&o.WAlpha, "walpha", o.WAlpha, "weight of the 2-regret in the weighted regret constructions (greedy starts, repair, GRASP)")
+	fs.Float64Var(&o.WBeta, "wbeta", o.WBeta, "weight of the cheapest insertion cost in the weighted regret constructions")
+	fs.IntVar(&o.Workers, "workers", o.Workers, "number of (method, run) jobs run in parallel (changes durations and time-budgeted results)")
+	fs.IntVar(&o.Iters, "iters", o.Iters, "metaheuristics: stop after exactly this many iterations instead of -budget (0 = use -budget)")
+}
+
+// optionSet registers the options of o on a private FlagSet
+func (o *Options) optionSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("options", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	o.Register(fs)
+	return fs
+}
+
+// lookupOption returns the flag of an option, nil if name is not an option
+func lookupOption(name string) *flag.Flag {
+	o := DefaultOptions()
+	return o.optionSet().Lookup(name)
+}
+
+// unfingerprinted are the options that do not change what a run does: the start types
+// select which methods are built (a method's start type is in its name), the stopping
+// rule is recorded per run (iterations column) and the rest concerns the instance or the
+// parallelism
+var unfingerprinted = map[string]bool{"starts": true, "budget": true, "iters": true, "sub": true, "lbiters": true, "workers": true}
+
+// Fingerprint hashes the values of every option that changes what a run does
+func (o Options) Fingerprint() string {
+	h := fnv.New64a()
+	o.optionSet().VisitAll(func(f *flag.Flag) {
+		if !unfingerprinted[f.Name] {
+			fmt.Fprintf(h, "%s=%s\n", f.Name, f.Value)
+		}
+	})
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// With returns a copy of o with the "name=value" settings applied in order
+func (o Options) With(settings ...map[string]string) (Options, error) {
+	fs := o.optionSet()
+	for _, s := range settings {
+		for name, v := range s {
+			if fs.Lookup(name) == nil {
+				return o, fmt.Errorf("unknown option -%s", name)
+			}
+			if err := fs.Set(name, v); err != nil {
+				return o, fmt.Errorf("-%s: %v", name, err)
+			}
+		}
+	}
+	return o, nil
+}
+
+// BuildMethods returns the methods described by o
+func BuildMethods(o Options) ([]Method, error) {
+	startTypes := strings.Split(o.Starts, ",")
+	for _, st := range startTypes {
+		if _, ok := Constructions[st]; !ok && st != "random" {
+			return nil, fmt.Errorf("unknown start type %q", st)
+		}
+	}
+
+	// the moves of every local search and metaheuristic
+	if o.Intra != "nodes" && o.Intra != "edges" {
+		return nil, fmt.Errorf("unknown -intra %q (available: nodes, edges)", o.Intra)
+	}
+	if o.Mode != "steepest" && o.Mode != "greedy" {
+		return nil, fmt.Errorf("unknown -mode %q (available: steepest, greedy)", o.Mode)
+	}
+
+	var methods []Method
+	switch o.Algo {
+	case "ls":
+		methods = LocalSearchMethods(startTypes)
+	case "construct":
+		for _, st := range startTypes {
+			methods = append(methods, ConstructionMethod(st))
+		}
+	case "ils":
+		if !slices.Contains(PerturbNames, o.Perturb) {
+			return nil, fmt.Errorf("unknown -perturb %q (available: %s)", o.Perturb, strings.Join(PerturbNames, ", "))
+		}
+		if !slices.Contains(AcceptRules, o.Accept) {
+			return nil, fmt.Errorf("unknown -accept %q (available: %s)", o.Accept, strings.Join(AcceptRules, ", "))
+		}
+		if o.Strength < 0 {
+			return nil, fmt.Errorf("invalid -strength %d: the replace perturbation cannot replace a negative number of nodes", o.Strength)
+		}
+		methods = []Method{ILSMethod(ILSOptions{
+			Mode:      o.Mode,
+			IntraMode: o.Intra,
+			Perturb:   o.Perturb,
+			Strength:  o.Strength,
+			Accept:    o.Accept,
+			Temp:      o.Temp,
+			Cooling:   o.Cooling,
+			Budget:    o.Budget,
+			Iters:     o.Iters,
+
+			EliteSize:   o.Elite,
+			RelinkEvery: o.Relink,
+			Relink:      PROptions{LSEvery: o.PRLS, Mode: o.Mode, IntraMode: o.Intra},
+		})}
+	case "lns":
+		if o.Destroy != "adaptive" && !slices.Contains(destroyOperators, o.Destroy) {
+			return nil, fmt.Errorf("unknown -destroy %q (available: %s, adaptive)", o.Destroy, strings.Join(destroyOperators, ", "))
+		}
+		methods = []Method{LNSMethod(LNSOptions{
+			Destroy:     o.Destroy,
+			LocalSearch: o.LNSLS,
+			Mode:        o.Mode,
+			IntraMode:   o.Intra,
+			Budget:      o.Budget,
+			Iters:       o.Iters,
+		})}
+	case "sa":
+		if !slices.Contains(SASchedules, o.Schedule) {
+			return nil, fmt.Errorf("unknown -schedule %q (available: %s)", o.Schedule, strings.Join(SASchedules, ", "))
+		}
+		methods = []Method{SAMethod(SAOptions{
+			IntraMode:  o.Intra,
+			Schedule:   o.Schedule,
+			Temp:       o.SATemp,
+			Alpha:      o.SAAlpha,
+			Beta:       o.SABeta,
+			EpochLen:   o.SAEpoch,
+			Budget:     o.Budget,
+			Iters:      o.Iters,
+			TraceEvery: 10,
+		})}
+	case "tabu":
+		if !slices.Contains(TenureModes, o.TenureMode) {
+			return nil, fmt.Errorf("unknown -tenuremode %q (available: %s)", o.TenureMode, strings.Join(TenureModes, ", "))
+		}
+		if o.Tenure < 0 {
+			return nil, fmt.Errorf("invalid -tenure %d (0 = K/4)", o.Tenure)
+		}
+		methods = []Method{TabuMethod(TabuOptions{
+			IntraMode:  o.Intra,
+			Tenure:     o.Tenure,
+			TenureMode: o.TenureMode,
+			Budget:     o.Budget,
+			Iters:      o.Iters,
+		})}
+	case "hea":
+		if o.Pop < 1 {
+			return nil, fmt.Errorf("invalid -pop %d: the population needs at least one member", o.Pop)
+		}
+		if Recombinations[o.XOver] == nil {
+			return nil, fmt.Errorf("unknown -xover %q (available: %s)", o.XOver, strings.Join(RecombinationNames(), ", "))
+		}
+		methods = []Method{HEAMethod(HEAOptions{
+			Crossover:   o.XOver,
+			PopSize:     o.Pop,
+			LocalSearch: o.HEALS,
+			Mode:        o.Mode,
+			IntraMode:   o.Intra,
+			Budget:      o.Budget,
+			Iters:       o.Iters,
+		})}
+	case "aco":
+		if o.Ants < 1 {
+			return nil, fmt.Errorf("invalid -ants %d: the colony needs at least one ant", o.Ants)
+		}
+		if o.Rho <= 0 || o.Rho > 1 {
+			return nil, fmt.Errorf("invalid -rho %g: the evaporation rate must be in (0, 1]", o.Rho)
+		}
+		methods = []Method{ACOMethod(ACOOptions{
+			Ants:       o.Ants,
+			Alpha:      o.ACOAlpha,
+			Beta:       o.ACOBeta,
+			Rho:        o.Rho,
+			Budget:     o.Budget,
+			Iters:      o.Iters,
+			TraceEvery: 1,
+		})}
+	case "grasp":
+		if !slices.Contains(RCLTypes, o.RCL) {
+			return nil, fmt.Errorf("unknown -rcl %q (available: %s)", o.RCL, strings.Join(RCLTypes, ", "))
+		}
+		if o.RCLSize < 1 {
+			return nil, fmt.Errorf("invalid -rclsize %d: the candidate list needs at least one node", o.RCLSize)
+		}
+		methods = []Method{GRASPMethod(GRASPOptions{
+			RCL:       o.RCL,
+			RCLSize:   o.RCLSize,
+			Alpha:     o.RCLAlpha,
+			Mode:      o.Mode,
+			IntraMode: o.Intra,
+			Budget:    o.Budget,
+			Iters:     o.Iters,
+		})}
+	case "vnd", "vns":
+		order, err := ParseNeighbourhoods(o.NBH)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -nbh: %v", err)
+		}
+		if o.Algo == "vns" && o.KMax < 1 {
+			return nil, fmt.Errorf("invalid -kmax %d: VNS needs at least one shaking move", o.KMax)
+		}
+		opts := VNSOptions{Neighbourhoods: order, KMax: o.KMax, Budget: o.Budget, Iters: o.Iters}
+		if o.Algo == "vnd" {
+			methods = []Method{VNDMethod(opts)}
+		} else {
+			methods = []Method{VNSMethod(opts)}
+		}
+	case "gls":
+		methods = []Method{GLSMethod(GLSOptions{
+			A:         o.GLSA,
+			Mode:      o.Mode,
+			IntraMode: o.Intra,
+			Budget:    o.Budget,
+			Iters:     o.Iters,
+		})}
+	default:
+		return nil, fmt.Errorf("unknown -algo %q", o.Algo)
+	}
+	weights := RegretWeights{Alpha: o.WAlpha, Beta: o.WBeta}
+	fingerprint := o.Fingerprint()
+	for i := range methods {
+		methods[i].Weights = &weights
+		methods[i].Options = fingerprint
+	}
+	return methods, nil
+}
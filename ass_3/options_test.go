@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+// TestBuildMethodsRejects checks that invalid option values fail instead of running
+// another algorithm under their name
+func TestBuildMethodsRejects(t *testing.T) {
+	for _, settings := range []map[string]string{
+		{"algo": "nope"},
+		{"algo": "ls", "starts": "random,nope"},
+		{"algo": "ils", "perturb": "nope"},
+		{"algo": "ils", "accept": "nope"},
+		{"algo": "ils", "strength": "-1"},
+		{"algo": "lns", "destroy": "nope"},
+		{"algo": "sa", "schedule": "nope"},
+		{"algo": "tabu", "tenuremode": "nope"},
+		{"algo": "tabu", "tenure": "-1"},
+		{"algo": "tabu", "intra": "nope"},
+		{"algo": "ils", "mode": "nope"},
+		{"algo": "aco", "ants": "0"},
+		{"algo": "aco", "rho": "0"},
+		{"algo": "aco", "rho": "1.5"},
+		{"algo": "grasp", "rcl": "nope"},
+		{"algo": "grasp", "rclsize": "0"},
+		{"algo": "vns", "kmax": "0"},
+	} {
+		o, err := DefaultOptions().With(settings)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := BuildMethods(o); err == nil {
+			t.Errorf("%v: no error", settings)
+		}
+	}
+}
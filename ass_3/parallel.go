@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// PARALLEL RUNS
+//
+// The (method, run) jobs of runMethods are independent: every job has its own RNG
+// (RunSeed) and the instance, the methods and the registries are only read. A pool of
+// workers runs the jobs concurrently and every job gets its own result channel, so
+// runMethods collects them in job order and the CSV rows come out in the same order as
+// a serial run. Parallel runs are opt-in (-workers, default 1): each job measures its own
+// wall time, and jobs running side by side share caches and memory bandwidth (with more
+// workers than CPU cores also the cores), so durations and the iterations reached by
+// time-budgeted methods are only comparable between runs with the same -workers.
+
+type jobResult struct {
+	res     RunResult
+	elapsed time.Duration
+}
+
+// runJobs starts job i = methodIndex*runs + run on `workers` goroutines and returns the
+// result channel of every job; jobs marked in done are skipped and get no result. Closing
+// quit stops handing out new jobs.
+func runJobs(inst *Instance, methods []Method, runs int, seed int64, workers int, done []bool, quit <-chan struct{}) []chan jobResult {
+	total := len(methods) * runs
+	results := make([]chan jobResult, total)
+	for i := range results {
+		results[i] = make(chan jobResult, 1)
+	}
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := 0; i < total; i++ {
+			if i%runs == 0 {
+				left := 0
+				for _, d := range done[i : i+runs] {
+					if !d {
+						left++
+					}
+				}
+				if left > 0 {
+					fmt.Printf("Running method %s with %d runs...\n", methods[i/runs].Name, left)
+				}
+			}
+			if done[i] {
+				continue
+			}
+			select {
+			case jobs <- i:
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	for w := 0; w < max(1, workers); w++ {
+		go func() {
+			for i := range jobs {
+				res, elapsed := runOnce(inst, methods[i/runs], i%runs, seed)
+				results[i] <- jobResult{res, elapsed}
+			}
+		}()
+	}
+	return results
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+)
+
+// PATH RELINKING
+//
+// Walk from a source solution towards a target one. First every node of the source
+// that is not in the target is replaced (best deltaReplaceAtPos among the target's
+// missing nodes), then 2-opt moves that increase the number of target edges are
+// applied (best delta first) until no such move exists. Local search is run on every
+// LSEvery-th intermediate solution. The best intermediate solution is returned.
+
+// PROptions configures PathRelink
+type PROptions struct {
+	LSEvery   int    // run local search on every LSEvery-th intermediate solution (0 = never)
+	Mode      string // local search: "steepest" or "greedy"
+	IntraMode string // "nodes" or "edges"
+}
+
+// ElitePool keeps up to Size distinct (by objective) good solutions
+type ElitePool struct {
+	Size    int
+	Members []Individual
+}
+
+// Offer adds the solution if there is room or it is better than the worst member;
+// solutions with an objective already in the pool are rejected
+func (p *ElitePool) Offer(inst *Instance, tour []int, inSel []bool) bool {
+	if p.Size <= 0 {
+		return false
+	}
+	obj := Objective(inst, tour)
+	if hasObjective(p.Members, obj) {
+		return false
+	}
+	ind := Individual{append([]int{}, tour...), append([]bool{}, inSel...), obj}
+	if len(p.Members) < p.Size {
+		p.Members = append(p.Members, ind)
+		return true
+	}
+	worst := worstIndividual(p.Members)
+	if obj < p.Members[worst].Obj {
+		p.Members[worst] = ind
+		return true
+	}
+	return false
+}
+
+// RecombinePathRelink uses path relinking (without intermediate local search) as a recombination operator
+func RecombinePathRelink(inst *Instance, p1 []int, p2 []int, rnd *rand.Rand) ([]int, []bool) {
+	child, inSel, _, _ := PathRelink(inst, p1, p2, PROptions{}, rnd)
+	return child, inSel
+}
+
+func PathRelink(inst *Instance, src []int, dst []int, opts PROptions, rnd *rand.Rand) (best []int, bestSel []bool, evals int, lsRuns int) {
+	N := inst.N
+	dist, nodes := inst.Dist, inst.Nodes
+	cur := append([]int{}, src...)
+	curSel := selectionOf(N, cur)
+	dstSel := selectionOf(N, dst)
+	dstNext, dstPrev := tourAdjacency(N, dst)
+	inDst := func(a, b int) int {
+		if dstNext[a] == b || dstPrev[a] == b {
+			return 1
+		}
+		return 0
+	}
+
+	bestObj := math.MaxInt
+	steps := 0
+	visit := func() {
+		steps++
+		cand, candSel := cur, curSel
+		if opts.LSEvery > 0 && steps%opts.LSEvery == 0 {
+			var e int
+			cand, candSel, e, _ = RunLocalSearch(inst, cur, curSel, opts.Mode, opts.IntraMode, rnd)
+			evals += e
+			lsRuns++
+		}
+		if obj := Objective(inst, cand); obj < bestObj {
+			best, bestSel, bestObj = append([]int{}, cand...), append([]bool{}, candSel...), obj
+		}
+	}
+
+	// phase 1: make the selections equal
+	for {
+		bestDelta, bestPos, bestU := math.MaxInt, -1, -1
+		for pos, v := range cur {
+			if dstSel[v] {
+				continue
+			}
+			for _, u := range dst {
+				if curSel[u] {
+					continue
+				}
+				delta := deltaReplaceAtPos(dist, nodes, cur, pos, u)
+				evals++
+				if delta < bestDelta {
+					bestDelta, bestPos, bestU = delta, pos, u
+				}
+			}
+		}
+		if bestPos < 0 {
+			break
+		}
+		applyReplace(cur, curSel, bestPos, bestU)
+		visit()
+	}
+
+	// phase 2: adopt the target's edges with 2-opt
+	K := len(cur)
+	for {
+		bestDelta, bi, bj := math.MaxInt, -1, -1
+		for i := 0; i < K; i++ {
+			for j := i + 1; j < K; j++ {
+				a, a1, b, b1 := cur[i], cur[(i+1)%K], cur[j], cur[(j+1)%K]
+				gain := inDst(a, b) + inDst(a1, b1) - inDst(a, a1) - inDst(b, b1)
+				if gain <= 0 {
+					continue
+				}
+				delta := delta2Opt(dist, nodes, cur, i, j)
+				evals++
+				if delta < bestDelta {
+					bestDelta, bi, bj = delta, i, j
+				}
+			}
+		}
+		if bi < 0 {
+			break
+		}
+		apply2Opt(cur, bi, bj)
+		visit()
+	}
+
+	if best == nil {
+		// identical solutions
+		best, bestSel = cur, curSel
+	}
+	return best, bestSel, evals, lsRuns
+}
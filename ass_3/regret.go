@@ -0,0 +1,185 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// INCREMENTAL 2-REGRET INSERTION
+//
+// The regret constructions need, for every unselected node, its cheapest and second
+// cheapest insertion into the tour. Recomputing them costs O(K) per node and step.
+// regretCache keeps both per node together with the edge (from -> to) they refer to.
+// Inserting w into edge a -> b only removes that edge and adds a -> w and w -> b, so a
+// node is fully recomputed only when its best or second best edge was a -> b; every
+// other node just compares the two new edges with its cached values.
+// Ties are resolved as in the full scan (bestInsertion): the earliest tour position wins.
+
+// RegretWeights weight the 2-regret (Alpha) and the cheapest insertion cost (Beta) in the
+// score of the weighted regret constructions (-walpha, -wbeta)
+type RegretWeights struct {
+	Alpha, Beta float64
+}
+
+// DefaultRegretWeights score a node by its 2-regret minus its cheapest insertion cost
+var DefaultRegretWeights = RegretWeights{Alpha: 1, Beta: 1}
+
+// weighted returns a shallow copy of inst whose regret constructions use w (inst if w is nil)
+func weighted(inst *Instance, w *RegretWeights) *Instance {
+	if w == nil || *w == inst.Weights {
+		return inst
+	}
+	view := *inst
+	view.Weights = *w
+	return &view
+}
+
+// candidate for weighted 2-regret insertion
+type regretCand struct {
+	node, bestTot, secondTot, bestPos int
+	score                             float64
+}
+
+type regretCache struct {
+	inst     *Instance
+	tour     []int
+	selected []bool
+	pos      []int // tour position of every selected node
+
+	best, second         []int // insertion increase (edges only) of the cheapest / second cheapest edge
+	bestFrom, bestTo     []int
+	secondFrom, secondTo []int
+}
+
+func newRegretCache(inst *Instance, tour []int, selected []bool) *regretCache {
+	n := inst.N
+	c := &regretCache{
+		inst: inst, tour: tour, selected: selected, pos: make([]int, n),
+		best: make([]int, n), second: make([]int, n),
+		bestFrom: make([]int, n), bestTo: make([]int, n),
+		secondFrom: make([]int, n), secondTo: make([]int, n),
+	}
+	for i, v := range tour {
+		c.pos[v] = i
+	}
+	for v := 0; v < n; v++ {
+		if !selected[v] {
+			c.refresh(v)
+		}
+	}
+	return c
+}
+
+// refresh recomputes the cached insertions of v over the whole tour
+func (c *regretCache) refresh(v int) {
+	D := c.inst.Dist
+	m := len(c.tour)
+	c.best[v], c.second[v] = math.MaxInt, math.MaxInt
+	c.bestFrom[v], c.secondFrom[v] = -1, -1
+	for i := 0; i < m; i++ {
+		a := c.tour[i]
+		b := c.tour[(i+1)%m]
+		inc := D[a][v] + D[v][b] - D[a][b]
+		if inc < c.best[v] {
+			c.second[v], c.secondFrom[v], c.secondTo[v] = c.best[v], c.bestFrom[v], c.bestTo[v]
+			c.best[v], c.bestFrom[v], c.bestTo[v] = inc, a, b
+		} else if inc < c.second[v] {
+			c.second[v], c.secondFrom[v], c.secondTo[v] = inc, a, b
+		}
+	}
+}
+
+// offer updates the cached insertions of v with the new edge from -> to
+func (c *regretCache) offer(v int, from int, to int) {
+	D := c.inst.Dist
+	inc := D[from][v] + D[v][to] - D[from][to]
+	if inc < c.best[v] || (inc == c.best[v] && c.pos[from] < c.pos[c.bestFrom[v]]) {
+		c.second[v], c.secondFrom[v], c.secondTo[v] = c.best[v], c.bestFrom[v], c.bestTo[v]
+		c.best[v], c.bestFrom[v], c.bestTo[v] = inc, from, to
+	} else if inc < c.second[v] {
+		c.second[v], c.secondFrom[v], c.secondTo[v] = inc, from, to
+	}
+}
+
+// insert puts node at tour position at (i.e. into the edge tour[at-1] -> tour[at])
+func (c *regretCache) insert(node int, at int) {
+	m := len(c.tour)
+	a := c.tour[at-1]
+	b := c.tour[at%m]
+	c.tour = insertAt(c.tour, at, node)
+	c.selected[node] = true
+	for i := at; i < len(c.tour); i++ {
+		c.pos[c.tour[i]] = i
+	}
+	for v := 0; v < c.inst.N; v++ {
+		if c.selected[v] {
+			continue
+		}
+		if (c.bestFrom[v] == a && c.bestTo[v] == b) || (c.secondFrom[v] == a && c.secondTo[v] == b) {
+			c.refresh(v)
+			continue
+		}
+		c.offer(v, a, node)
+		c.offer(v, node, b)
+	}
+}
+
+// candidates lists the unselected nodes in increasing index with their weighted 2-regret score
+func (c *regretCache) candidates() []regretCand {
+	alpha := c.inst.Weights.Alpha
+	beta := c.inst.Weights.Beta
+	nodes := c.inst.Nodes
+	var cands []regretCand
+	for v := 0; v < c.inst.N; v++ {
+		if c.selected[v] {
+			continue
+		}
+		secondInc := c.second[v]
+		if secondInc == math.MaxInt {
+			secondInc = c.best[v]
+		}
+		bestTot := c.best[v] + nodes[v].Cost
+		secondTot := secondInc + nodes[v].Cost
+		regret := secondTot - bestTot
+		score := alpha*float64(regret) - beta*float64(bestTot)
+		cands = append(cands, regretCand{v, bestTot, secondTot, c.pos[c.bestFrom[v]] + 1, score})
+	}
+	return cands
+}
+
+// sorted returns the candidates sorted by decreasing score
+func (c *regretCache) sorted() []regretCand {
+	cands := c.candidates()
+	sortByScore(cands)
+	return cands
+}
+
+// top returns the candidate with the highest score (the first one after sortByScore)
+func (c *regretCache) top() regretCand {
+	cands := c.candidates()
+	ch, tie := pickFirst(cands, func(x, y regretCand) bool { return x.score > y.score })
+	if tie {
+		sortByScore(cands)
+		ch = cands[0]
+	}
+	return ch
+}
+
+func sortByScore(cands []regretCand) {
+	sort.Slice(cands, func(a, b int) bool { return cands[a].score > cands[b].score })
+}
+
+// pickFirst returns the candidate ordered first by better and whether another candidate ties with it
+// (in which case the caller must fall back to the full sort to pick the same one)
+func pickFirst(cands []regretCand, better func(x, y regretCand) bool) (regretCand, bool) {
+	ch := cands[0]
+	tie := false
+	for _, x := range cands[1:] {
+		if better(x, ch) {
+			ch, tie = x, false
+		} else if !better(ch, x) {
+			tie = true
+		}
+	}
+	return ch, tie
+}
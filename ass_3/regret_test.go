@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// referenceCandidates recomputes the weighted 2-regret candidates of every unselected node
+// over the whole tour, as the constructions did before regretCache
+func referenceCandidates(inst *Instance, tour []int, selected []bool) []regretCand {
+	var cands []regretCand
+	m := len(tour)
+	for v := 0; v < inst.N; v++ {
+		if selected[v] {
+			continue
+		}
+		best, bestPos := bestInsertion(v, tour, inst.Dist)
+		second := -1
+		for i := 0; i < m; i++ {
+			if i+1 == bestPos {
+				continue
+			}
+			a, b := tour[i], tour[(i+1)%m]
+			if inc := inst.Dist[a][v] + inst.Dist[v][b] - inst.Dist[a][b]; second < 0 || inc < second {
+				second = inc
+			}
+		}
+		if second < 0 {
+			second = best
+		}
+		bestTot := best + inst.Nodes[v].Cost
+		secondTot := second + inst.Nodes[v].Cost
+		score := inst.Weights.Alpha*float64(secondTot-bestTot) - inst.Weights.Beta*float64(bestTot)
+		cands = append(cands, regretCand{v, bestTot, secondTot, bestPos, score})
+	}
+	return cands
+}
+
+// checkRegretCache extends tour to K nodes with both the cache and the reference,
+// comparing every candidate and every choice; it returns the completed tour and the number
+// of steps where the best score was tied (and top fell back to the sort)
+func checkRegretCache(inst *Instance, tour []int, selected []bool) (refTour []int, ties int, err error) {
+	refTour = append([]int{}, tour...)
+	refSel := append([]bool{}, selected...)
+	c := newRegretCache(inst, append([]int{}, tour...), append([]bool{}, selected...))
+	for len(c.tour) < inst.K {
+		got := c.candidates()
+		want := referenceCandidates(inst, refTour, refSel)
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			for i := range want {
+				if got[i] != want[i] {
+					return nil, ties, fmt.Errorf("tour size %d: cache %+v, reference %+v", len(c.tour), got[i], want[i])
+				}
+			}
+			return nil, ties, fmt.Errorf("tour size %d: %d candidates, reference has %d", len(c.tour), len(got), len(want))
+		}
+		if _, tie := pickFirst(got, func(x, y regretCand) bool { return x.score > y.score }); tie {
+			ties++
+		}
+		sortByScore(want)
+		ch := c.top()
+		if ch != want[0] {
+			return nil, ties, fmt.Errorf("tour size %d: cache picks %+v, sorted reference %+v", len(c.tour), ch, want[0])
+		}
+		c.insert(ch.node, ch.bestPos)
+		refTour = insertAt(refTour, want[0].bestPos, want[0].node)
+		refSel[want[0].node] = true
+		if fmt.Sprint(c.tour) != fmt.Sprint(refTour) {
+			return nil, ties, fmt.Errorf("tour size %d: cache tour %v, reference %v", len(c.tour), c.tour, refTour)
+		}
+	}
+	return refTour, ties, nil
+}
+
+// TestRegretCacheMatchesReference runs GreedyRegretStart from several start nodes and
+// RegretRepair on a destroyed tour, with the default and a fractional weighting, and checks
+// that both the tie fallback and the plain pickFirst path were taken
+func TestRegretCacheMatchesReference(t *testing.T) {
+	for _, path := range testInstances {
+		base := readTestInstance(t, path)
+		for _, w := range []RegretWeights{DefaultRegretWeights, {Alpha: 0.37, Beta: 1.13}} {
+			inst := weighted(base, &w)
+			steps, ties := 0, 0
+			for _, start := range []int{0, 1, 57, 123, inst.N - 1} {
+				tour, selected := regretStartPair(inst, start)
+				want, n, err := checkRegretCache(inst, tour, selected)
+				if err != nil {
+					t.Fatalf("%s %+v start %d: %v", path, w, start, err)
+				}
+				ties += n
+				steps += inst.K - 2
+				if got, _ := GreedyRegretStart(inst, start); fmt.Sprint(got) != fmt.Sprint(want) {
+					t.Errorf("%s %+v start %d: GreedyRegretStart differs from the reference construction", path, w, start)
+				}
+			}
+			// repair after removing every third node of a greedy tour
+			full, _ := GreedyRegretStart(inst, 0)
+			var partial []int
+			selected := make([]bool, inst.N)
+			for i, v := range full {
+				if i%3 != 0 {
+					partial = append(partial, v)
+					selected[v] = true
+				}
+			}
+			want, n, err := checkRegretCache(inst, partial, selected)
+			if err != nil {
+				t.Fatalf("%s %+v repair: %v", path, w, err)
+			}
+			if got := RegretRepair(inst, partial, selected); fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("%s %+v: RegretRepair differs from the reference construction", path, w)
+			}
+			ties += n
+			steps += inst.K - len(partial)
+			if w == DefaultRegretWeights && ties == 0 {
+				t.Errorf("%s %+v: no tied step, the sort fallback of top is not covered", path, w)
+			}
+			if ties == steps {
+				t.Errorf("%s %+v: every step tied, pickFirst alone is not covered", path, w)
+			}
+		}
+	}
+}
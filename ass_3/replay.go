@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// REPRODUCIBILITY
+//
+// A run is a function of the instance, the method, the options that built it, the run
+// index and the -seed printed at start only. Method names leave out most options, so
+// every method carries the fingerprint of its options (Options.Fingerprint):
+// - the run's RNG is seeded with RunSeed(seed, method, run) from the method name and the
+//   fingerprint, not drawn from a shared stream, so a run does not depend on the other
+//   methods or runs, and configurations differing in any option get different seeds;
+// - every random choice of a start solution and of a method goes through that RNG;
+// - the metaheuristics stop on a time budget, which is the only non-deterministic input.
+//   The iterations they complete are written to the results CSV, and a replay with that
+//   iteration count (-iters, or the 4th field of -replay) repeats the run exactly.
+// The fingerprint is written to the results CSV; given as the 5th field of -replay it
+// makes a replay with other options fail instead of silently running another configuration.
+// -replay runs one run in isolation; replay_test.go checks the contract on results CSVs.
+
+// budget ends a search loop after a time limit or, when iters > 0, after exactly iters iterations
+type budget struct {
+	start time.Time
+	limit time.Duration
+	iters int
+}
+
+func newBudget(limit time.Duration, iters int) budget {
+	return budget{start: time.Now(), limit: limit, iters: iters}
+}
+
+// left reports whether another iteration may start after done iterations
+func (b budget) left(done int) bool {
+	if b.iters > 0 {
+		return done < b.iters
+	}
+	return time.Since(b.start) < b.limit
+}
+
+// RunSeed derives the seed of one run from the experiment seed, the method name and
+// options fingerprint and the run index
+func RunSeed(seed int64, m Method, run int) int64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(seed))
+	h.Write(buf[:])
+	h.Write([]byte(m.Name))
+	h.Write([]byte(m.Options))
+	binary.LittleEndian.PutUint64(buf[:], uint64(run))
+	h.Write(buf[:])
+	return int64(h.Sum64() & (1<<63 - 1))
+}
+
+// runOnce performs run `run` of method m; elapsed covers the improvement only
+func runOnce(inst *Instance, m Method, run int, seed int64) (res RunResult, elapsed time.Duration) {
+	inst = weighted(inst, m.Weights)
+	rnd := rand.New(rand.NewSource(RunSeed(seed, m, run)))
+	tour, inSel := StartSolution(inst, m.StartType, run, rnd)
+	start := time.Now()
+	res = m.Improve(inst, tour, inSel, rnd)
+	return res, time.Since(start)
+}
+
+// withIterations returns o set to replay exactly iters iterations
+func withIterations(o Options, iters int) Options {
+	o.Iters = iters
+	if iters == 0 {
+		// no iteration at all: an empty time budget
+		o.Budget = 0
+	}
+	return o
+}
+
+func findMethod(methods []Method, name string) (Method, error) {
+	var names []string
+	for _, m := range methods {
+		if m.Name == name {
+			return m, nil
+		}
+		names = append(names, m.Name)
+	}
+	return Method{}, fmt.Errorf("method %q is not built by these flags (available: %s)", name, strings.Join(names, ", "))
+}
+
+// replaySpec is one run of -replay; iters < 0 keeps the time budget of the options and
+// an empty options fingerprint is not checked
+type replaySpec struct {
+	method  string
+	run     int
+	seed    int64
+	iters   int
+	options string
+}
+
+// parseReplay reads "method,run,seed[,iterations[,options]]"
+func parseReplay(spec string) (replaySpec, error) {
+	parts := strings.Split(spec, ",")
+	if len(parts) < 3 || len(parts) > 5 {
+		return replaySpec{}, fmt.Errorf("expected method,run,seed[,iterations[,options]], got %q", spec)
+	}
+	r := replaySpec{method: parts[0], iters: -1}
+	var err error
+	if r.run, err = strconv.Atoi(parts[1]); err != nil {
+		return r, fmt.Errorf("bad run %q", parts[1])
+	}
+	if r.seed, err = strconv.ParseInt(parts[2], 10, 64); err != nil {
+		return r, fmt.Errorf("bad seed %q", parts[2])
+	}
+	if len(parts) >= 4 {
+		if r.iters, err = strconv.Atoi(parts[3]); err != nil || r.iters < 0 {
+			return r, fmt.Errorf("bad iteration count %q", parts[3])
+		}
+	}
+	if len(parts) == 5 {
+		r.options = parts[4]
+	}
+	return r, nil
+}
+
+// replayRun performs run r of the method built from base, with the iteration count of r
+func replayRun(inst *Instance, r replaySpec, base Options) (Method, RunResult, time.Duration, error) {
+	if r.iters >= 0 {
+		base = withIterations(base, r.iters)
+	}
+	methods, err := BuildMethods(base)
+	if err != nil {
+		return Method{}, RunResult{}, 0, err
+	}
+	m, err := findMethod(methods, r.method)
+	if err != nil {
+		return Method{}, RunResult{}, 0, err
+	}
+	if r.options != "" && r.options != m.Options {
+		return Method{}, RunResult{}, 0, fmt.Errorf("%s was run with other options (fingerprint %s, these flags give %s)", m.Name, r.options, m.Options)
+	}
+	res, elapsed := runOnce(inst, m, r.run, r.seed)
+	return m, res, elapsed, nil
+}
+
+// Replay runs "method,run,seed[,iterations[,options]]" in isolation and prints the result
+func Replay(inst *Instance, spec string, base Options) error {
+	r, err := parseReplay(spec)
+	if err != nil {
+		return err
+	}
+	m, res, elapsed, err := replayRun(inst, r, base)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Replayed %s run %d (seed %d, run seed %d) in %v\n", m.Name, r.run, r.seed, RunSeed(r.seed, m, r.run), elapsed)
+	fmt.Printf("objective %d (length %d, costs %d), iterations %d\ntour %v\n",
+		Objective(inst, res.Tour), TourLength(inst.Dist, res.Tour), SelectedCosts(inst.Nodes, res.Tour), res.Iters, res.Tour)
+	return ValidateSolution(inst, res.Tour, selectionOf(inst.N, res.Tour))
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readResults reads a results CSV into one map per row, keyed by the header
+func readResults(t *testing.T, path string) []map[string]string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) == 0 || !slices.Equal(records[0], resultHeader) {
+		t.Fatalf("%s: unexpected header", path)
+	}
+	var rows []map[string]string
+	for _, rec := range records[1:] {
+		row := map[string]string{}
+		for i, name := range records[0] {
+			row[name] = rec[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// TestReplayReproducesResults runs every algorithm on a time budget (two workers, so the
+// runs interleave) and replays each CSV row from its method, run, the experiment seed and
+// its iteration count; the replay must give the row's objective and tour
+func TestReplayReproducesResults(t *testing.T) {
+	const (
+		runs = 2
+		seed = 7
+	)
+	inst := SubInstance(readTestInstance(t, "../TSPA.csv"), 60)
+	for _, algo := range []string{"ls", "ils", "lns", "sa", "tabu", "hea", "aco", "grasp", "vns", "gls"} {
+		opts, err := DefaultOptions().With(map[string]string{"algo": algo, "starts": "random,greedy"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts.Budget = 20 * time.Millisecond
+		methods, err := BuildMethods(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := filepath.Join(t.TempDir(), algo+".csv")
+		if err := runMethods(inst, methods, runs, seed, 2, out, "", 0, false); err != nil {
+			t.Fatal(err)
+		}
+		rows := readResults(t, out)
+		if len(rows) != len(methods)*runs {
+			t.Fatalf("%s: %d rows, want %d", algo, len(rows), len(methods)*runs)
+		}
+		for _, row := range rows {
+			spec := fmt.Sprintf("%s,%s,%d,%s,%s", row["method"], row["run"], seed, row["iterations"], row["options"])
+			r, err := parseReplay(spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, res, _, err := replayRun(inst, r, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tour := make([]string, len(res.Tour))
+			for i, v := range res.Tour {
+				tour[i] = strconv.Itoa(v)
+			}
+			obj := strconv.Itoa(Objective(inst, res.Tour))
+			if obj != row["objective"] || strings.Join(tour, ";") != row["final_selected"] {
+				t.Errorf("replay %s: objective %s, the CSV row has %s", spec, obj, row["objective"])
+			}
+		}
+	}
+}
+
+// TestReplayChecksOptions changes options that the method names leave out: the run seeds
+// must change, and a replay with the recorded fingerprint must fail
+func TestReplayChecksOptions(t *testing.T) {
+	inst := SubInstance(readTestInstance(t, "../TSPA.csv"), 40)
+	base, err := DefaultOptions().With(map[string]string{"algo": "ils", "perturb": "replace", "iters": "3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms, err := BuildMethods(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, changed := range []map[string]string{{"strength": "3"}, {"temp": "5"}, {"walpha": "0.5"}} {
+		o, err := base.With(changed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		other, err := BuildMethods(o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if other[0].Name != ms[0].Name {
+			t.Fatalf("%v changes the method name, pick an option it leaves out", changed)
+		}
+		if RunSeed(1, other[0], 0) == RunSeed(1, ms[0], 0) {
+			t.Errorf("%v: same run seed", changed)
+		}
+		spec := fmt.Sprintf("%s,0,1,3,%s", ms[0].Name, ms[0].Options)
+		r, err := parseReplay(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, _, err := replayRun(inst, r, o); err == nil {
+			t.Errorf("%v: replay of %s accepted", changed, spec)
+		}
+	}
+	// the stopping rule is not part of the fingerprint
+	if withIterations(base, 0).Fingerprint() != base.Fingerprint() {
+		t.Errorf("the iteration count changes the fingerprint")
+	}
+}
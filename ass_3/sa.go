@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// SIMULATED ANNEALING
+//
+// Every iteration samples one random move (intra: node swap or 2-opt, inter: node
+// replacement), evaluates it with the delta functions and accepts it with the
+// Metropolis rule. The temperature is updated after every epoch of EpochLen moves.
+
+// SAOptions configures SimulatedAnnealing
+type SAOptions struct {
+	IntraMode  string        // "nodes" or "edges"
+	Schedule   string        // one of SASchedules
+	Temp       float64       // initial temperature, 0 = calibrate from sampled deltas
+	Alpha      float64       // geometric factor per epoch ("geometric", "reheat")
+	Beta       float64       // Lundy-Mees parameter: T = T / (1 + Beta*T)
+	EpochLen   int           // moves between temperature updates
+	Budget     time.Duration // total running time
+	Iters      int           // exact number of iterations instead of Budget (0 = off)
+	TraceEvery int           // record a trace point every TraceEvery epochs (0 = never)
+}
+
+// SASchedules are the values of SAOptions.Schedule
+var SASchedules = []string{"geometric", "lundy-mees", "reheat"}
+
+const (
+	saCalibrationSamples = 1000
+	saInitialAcceptProb  = 0.8  // acceptance probability of an average worsening move at T0
+	saReheatRatio        = 0.01 // reheat when less than 1% of an epoch's moves were accepted
+	saReheatFraction     = 0.5  // reheat to this fraction of T0
+)
+
+// SAMethod wraps SimulatedAnnealing as a runnable method starting from random solutions
+func SAMethod(opts SAOptions) Method {
+	return Method{
+		Name:      fmt.Sprintf("sa_intra:%s_schedule:%s", opts.IntraMode, opts.Schedule),
+		StartType: "random",
+		Improve: func(inst *Instance, tour []int, inSel []bool, rnd *rand.Rand) RunResult {
+			return SimulatedAnnealing(inst, tour, inSel, opts, rnd)
+		},
+	}
+}
+
+// saMove is a sampled move: intra (i<j) or inter (pos, unselected index)
+type saMove struct {
+	inter bool
+	a, b  int
+}
+
+func SimulatedAnnealing(inst *Instance, tour []int, inSel []bool, opts SAOptions, rnd *rand.Rand) RunResult {
+	stop := newBudget(opts.Budget, opts.Iters)
+	res := RunResult{}
+
+	cur := append([]int{}, tour...)
+	curSel := append([]bool{}, inSel...)
+	unselected := make([]int, 0, inst.N-len(cur))
+	for v := 0; v < inst.N; v++ {
+		if !curSel[v] {
+			unselected = append(unselected, v)
+		}
+	}
+	curObj := Objective(inst, cur)
+	best := append([]int{}, cur...)
+	bestObj := curObj
+
+	sample := func() (saMove, int) {
+		K := len(cur)
+		if len(unselected) > 0 && rnd.Intn(2) == 0 {
+			pos := rnd.Intn(K)
+			idx := rnd.Intn(len(unselected))
+			return saMove{true, pos, idx}, deltaReplaceAtPos(inst.Dist, inst.Nodes, cur, pos, unselected[idx])
+		}
+		i := rnd.Intn(K)
+		j := rnd.Intn(K - 1)
+		if j >= i {
+			j++
+		}
+		if i > j {
+			i, j = j, i
+		}
+		if opts.IntraMode == "nodes" {
+			return saMove{false, i, j}, deltaSwapPositions(inst.Dist, inst.Nodes, cur, i, j)
+		}
+		return saMove{false, i, j}, delta2Opt(inst.Dist, inst.Nodes, cur, i, j)
+	}
+
+	T0 := opts.Temp
+	if T0 <= 0 {
+		T0 = calibrateTemperature(func() int {
+			_, d := sample()
+			return d
+		})
+		res.Evals += saCalibrationSamples
+	}
+	T := T0
+	epochLen := opts.EpochLen
+	if epochLen <= 0 {
+		epochLen = 1000
+	}
+
+	for epoch := 0; stop.left(res.Iters); epoch++ {
+		res.Iters++
+		accepted := 0
+		for it := 0; it < epochLen; it++ {
+			mv, delta := sample()
+			res.Evals++
+			if delta > 0 && (T <= 0 || rnd.Float64() >= math.Exp(-float64(delta)/T)) {
+				continue
+			}
+			accepted++
+			switch {
+			case mv.inter:
+				old := cur[mv.a]
+				applyReplace(cur, curSel, mv.a, unselected[mv.b])
+				unselected[mv.b] = old
+			case opts.IntraMode == "nodes":
+				applySwap(cur, mv.a, mv.b)
+			default:
+				apply2Opt(cur, mv.a, mv.b)
+			}
+			curObj += delta
+			if delta < 0 {
+				res.Improvements++
+			}
+			if curObj < bestObj {
+				bestObj = curObj
+				best = append(best[:0], cur...)
+			}
+		}
+
+		if opts.TraceEvery > 0 && epoch%opts.TraceEvery == 0 {
+			res.Trace = append(res.Trace, TracePoint{Iter: (epoch + 1) * epochLen, Objective: curObj, Best: bestObj, Value: T})
+		}
+
+		switch opts.Schedule {
+		case "lundy-mees":
+			T = T / (1 + opts.Beta*T)
+		case "reheat":
+			if float64(accepted) < saReheatRatio*float64(epochLen) {
+				T = saReheatFraction * T0
+			} else {
+				T *= opts.Alpha
+			}
+		default: // "geometric"
+			T *= opts.Alpha
+		}
+	}
+	res.Tour = best
+	return res
+}
+
+// calibrateTemperature picks T0 so that an average worsening move is accepted with probability saInitialAcceptProb
+func calibrateTemperature(sampleDelta func() int) float64 {
+	sum, count := 0, 0
+	for i := 0; i < saCalibrationSamples; i++ {
+		if d := sampleDelta(); d > 0 {
+			sum += d
+			count++
+		}
+	}
+	if count == 0 {
+		return 1
+	}
+	avg := float64(sum) / float64(count)
+	return -avg / math.Log(saInitialAcceptProb)
+}
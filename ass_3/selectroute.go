@@ -0,0 +1,317 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// SELECTION FIRST, ROUTING SECOND
+//
+// Two-phase constructions: choose the K nodes first, then route them with a TSP heuristic.
+// Selection rules (the start node is always selected):
+// - "score":    the K-1 nodes with the lowest cost + distance to the start node,
+// - "medoids":  k-medoids clustering of all nodes; whole clusters are taken, the start's
+//               first and the others by mean (cost + distance to the medoid), until K nodes,
+// - "knapsack": greedy by estimated marginal objective, cost + half the distance to the
+//               two nearest selected nodes (the length of a detour through the node).
+// Routing:
+// - "nn":  nearest neighbour tour from the start node followed by 2-opt,
+// - "mst": Christofides-like tour: MST plus a greedy matching of its odd-degree nodes,
+//          an Euler tour of the union and shortcuts of repeated nodes.
+
+type selectionRule func(inst *Instance, startNode int) []int
+type routingRule func(inst *Instance, nodes []int) []int
+
+// selectRoute combines a selection and a routing rule into a Construction
+func selectRoute(sel selectionRule, route routingRule) Construction {
+	return func(inst *Instance, startNode int) ([]int, []bool) {
+		chosen := sel(inst, startNode)
+		return route(inst, chosen), selectionOf(inst.N, chosen)
+	}
+}
+
+// selectByScore picks the start node and the K-1 nodes with the lowest cost + distance to it
+func selectByScore(inst *Instance, startNode int) []int {
+	var others []int
+	for v := 0; v < inst.N; v++ {
+		if v != startNode {
+			others = append(others, v)
+		}
+	}
+	score := func(v int) int { return inst.Nodes[v].Cost + inst.Dist[startNode][v] }
+	sort.SliceStable(others, func(a, b int) bool { return score(others[a]) < score(others[b]) })
+	return append([]int{startNode}, others[:inst.K-1]...)
+}
+
+// selectByMedoids clusters all nodes around K/10 medoids and takes whole clusters
+func selectByMedoids(inst *Instance, startNode int) []int {
+	D := inst.Dist
+	m := max(2, inst.K/10)
+	// farthest-first initial medoids, starting with the start node
+	medoids := []int{startNode}
+	near := append([]int{}, D[startNode]...)
+	for len(medoids) < m {
+		far := 0
+		for v := range near {
+			if near[v] > near[far] {
+				far = v
+			}
+		}
+		medoids = append(medoids, far)
+		for v := range near {
+			near[v] = min(near[v], D[far][v])
+		}
+	}
+
+	assign := make([]int, inst.N)
+	for iter := 0; iter < 20; iter++ {
+		for v := 0; v < inst.N; v++ {
+			assign[v] = 0
+			for c := 1; c < m; c++ {
+				if D[v][medoids[c]] < D[v][medoids[assign[v]]] {
+					assign[v] = c
+				}
+			}
+		}
+		// the medoid of a cluster minimises the distance sum to its members;
+		// cluster 0 keeps the start node so that it stays selected
+		changed := false
+		for c := 1; c < m; c++ {
+			best, bestSum := medoids[c], math.MaxInt
+			for u := 0; u < inst.N; u++ {
+				if assign[u] != c {
+					continue
+				}
+				sum := 0
+				for v := 0; v < inst.N; v++ {
+					if assign[v] == c {
+						sum += D[u][v]
+					}
+				}
+				if sum < bestSum {
+					best, bestSum = u, sum
+				}
+			}
+			if best != medoids[c] {
+				medoids[c] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	members := make([][]int, m)
+	for v := 0; v < inst.N; v++ {
+		members[assign[v]] = append(members[assign[v]], v)
+	}
+	key := func(v int) int { return inst.Nodes[v].Cost + D[v][medoids[assign[v]]] }
+	mean := make([]float64, m)
+	for c := range members {
+		sort.SliceStable(members[c], func(a, b int) bool { return key(members[c][a]) < key(members[c][b]) })
+		for _, v := range members[c] {
+			mean[c] += float64(key(v))
+		}
+		mean[c] /= float64(max(1, len(members[c])))
+	}
+	order := make([]int, 0, m)
+	for c := 1; c < m; c++ {
+		order = append(order, c)
+	}
+	sort.SliceStable(order, func(a, b int) bool { return mean[order[a]] < mean[order[b]] })
+	order = append([]int{0}, order...)
+
+	chosen := []int{startNode}
+	for _, c := range order {
+		for _, v := range members[c] {
+			if len(chosen) == inst.K {
+				return chosen
+			}
+			if v != startNode {
+				chosen = append(chosen, v)
+			}
+		}
+	}
+	return chosen
+}
+
+// selectByKnapsack adds the node with the lowest cost + (d1 + d2) / 2 until K nodes,
+// d1 and d2 being its distances to the two nearest selected nodes
+func selectByKnapsack(inst *Instance, startNode int) []int {
+	D := inst.Dist
+	selected := make([]bool, inst.N)
+	selected[startNode] = true
+	chosen := []int{startNode}
+	d1 := append([]int{}, D[startNode]...)
+	d2 := make([]int, inst.N)
+	for v := range d2 {
+		d2[v] = math.MaxInt
+	}
+	for len(chosen) < inst.K {
+		bestV, bestEst := -1, math.MaxInt
+		for v := 0; v < inst.N; v++ {
+			if selected[v] {
+				continue
+			}
+			second := d2[v]
+			if second == math.MaxInt {
+				second = d1[v]
+			}
+			// twice the estimate, to stay in integers
+			if est := 2*inst.Nodes[v].Cost + d1[v] + second; est < bestEst {
+				bestV, bestEst = v, est
+			}
+		}
+		selected[bestV] = true
+		chosen = append(chosen, bestV)
+		for v := 0; v < inst.N; v++ {
+			if d := D[bestV][v]; d < d1[v] {
+				d1[v], d2[v] = d, d1[v]
+			} else if d < d2[v] {
+				d2[v] = d
+			}
+		}
+	}
+	return chosen
+}
+
+// routeNN2Opt builds a nearest neighbour tour from nodes[0] and improves it with 2-opt
+func routeNN2Opt(inst *Instance, nodes []int) []int {
+	D := inst.Dist
+	visited := make(map[int]bool, len(nodes))
+	tour := []int{nodes[0]}
+	visited[nodes[0]] = true
+	for len(tour) < len(nodes) {
+		last := tour[len(tour)-1]
+		next := -1
+		for _, v := range nodes {
+			if !visited[v] && (next < 0 || D[last][v] < D[last][next]) {
+				next = v
+			}
+		}
+		visited[next] = true
+		tour = append(tour, next)
+	}
+	twoOpt(inst, tour)
+	return tour
+}
+
+// twoOpt applies improving edge exchanges (first improvement) until none is left
+func twoOpt(inst *Instance, tour []int) {
+	K := len(tour)
+	for improved := true; improved; {
+		improved = false
+		for i := 0; i < K-2; i++ {
+			for j := i + 2; j < K; j++ {
+				if i == 0 && j == K-1 {
+					continue // the two edges share tour[0]
+				}
+				if delta2Opt(inst.Dist, inst.Nodes, tour, i, j) < 0 {
+					apply2Opt(tour, i, j)
+					improved = true
+				}
+			}
+		}
+	}
+}
+
+// routeChristofides routes nodes like Christofides, with a greedy instead of a
+// minimum weight perfect matching; the tour starts at nodes[0]
+func routeChristofides(inst *Instance, nodes []int) []int {
+	D := inst.Dist
+	n := len(nodes)
+	type edge struct{ a, b int } // indices into nodes
+	var edges []edge
+
+	// Prim's MST
+	inTree := make([]bool, n)
+	dist := make([]int, n)
+	parent := make([]int, n)
+	for i := range dist {
+		dist[i] = math.MaxInt
+	}
+	dist[0] = 0
+	degree := make([]int, n)
+	for step := 0; step < n; step++ {
+		u := -1
+		for i := 0; i < n; i++ {
+			if !inTree[i] && (u < 0 || dist[i] < dist[u]) {
+				u = i
+			}
+		}
+		inTree[u] = true
+		if step > 0 {
+			edges = append(edges, edge{parent[u], u})
+			degree[parent[u]]++
+			degree[u]++
+		}
+		for i := 0; i < n; i++ {
+			if !inTree[i] && D[nodes[u]][nodes[i]] < dist[i] {
+				dist[i], parent[i] = D[nodes[u]][nodes[i]], u
+			}
+		}
+	}
+
+	// greedy matching of the odd-degree nodes, shortest pairs first
+	var odd []int
+	for i := 0; i < n; i++ {
+		if degree[i]%2 == 1 {
+			odd = append(odd, i)
+		}
+	}
+	var pairs []edge
+	for x := 0; x < len(odd); x++ {
+		for y := x + 1; y < len(odd); y++ {
+			pairs = append(pairs, edge{odd[x], odd[y]})
+		}
+	}
+	sort.SliceStable(pairs, func(x, y int) bool {
+		return D[nodes[pairs[x].a]][nodes[pairs[x].b]] < D[nodes[pairs[y].a]][nodes[pairs[y].b]]
+	})
+	matched := make([]bool, n)
+	for _, p := range pairs {
+		if !matched[p.a] && !matched[p.b] {
+			matched[p.a], matched[p.b] = true, true
+			edges = append(edges, edge{p.a, p.b})
+		}
+	}
+
+	// Euler tour (Hierholzer) of the multigraph, shortcutting repeated nodes
+	adj := make([][]int, n)
+	for id, e := range edges {
+		adj[e.a] = append(adj[e.a], id)
+		adj[e.b] = append(adj[e.b], id)
+	}
+	used := make([]bool, len(edges))
+	next := make([]int, n)
+	stack := []int{0}
+	var euler []int
+	for len(stack) > 0 {
+		u := stack[len(stack)-1]
+		for next[u] < len(adj[u]) && used[adj[u][next[u]]] {
+			next[u]++
+		}
+		if next[u] == len(adj[u]) {
+			euler = append(euler, u)
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		id := adj[u][next[u]]
+		used[id] = true
+		v := edges[id].a
+		if v == u {
+			v = edges[id].b
+		}
+		stack = append(stack, v)
+	}
+	seen := make([]bool, n)
+	tour := make([]int, 0, n)
+	for i := len(euler) - 1; i >= 0; i-- {
+		if !seen[euler[i]] {
+			seen[euler[i]] = true
+			tour = append(tour, nodes[euler[i]])
+		}
+	}
+	return tour
+}
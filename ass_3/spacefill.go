@@ -0,0 +1,245 @@
+package main
+
+import (
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// FAST STARTS FOR LARGE INSTANCES
+//
+// Both constructions route a cost-filtered node set: the start node and the K-1 cheapest
+// other nodes, found by one O(N log N) sort instead of the O(N*K^2) regret construction.
+// - "hilbert": order the set along a Hilbert curve over the bounding box, O(N log N) in total.
+// - "hull":    convex hull of the set (O(K log K)), then insertion of the remaining nodes,
+//              outermost first. Cheapest insertion over the whole tour would cost O(K^2);
+//              instead a node only tries the edges on both sides of its hullNeighbours
+//              nearest tour nodes along the Hilbert curve, found in O(log K) with a Fenwick
+//              tree over the curve ranks, so the whole construction is O(N log N).
+// Tours are rotated to begin with the start node.
+
+const (
+	hilbertOrder   = 16 // bits per coordinate of the Hilbert grid
+	hullNeighbours = 8  // tour nodes on each side along the Hilbert curve tried by the hull insertion
+)
+
+// costFiltered returns the start node and the K-1 cheapest other nodes
+func costFiltered(inst *Instance, startNode int) []int {
+	others := make([]int, 0, inst.N-1)
+	for v := 0; v < inst.N; v++ {
+		if v != startNode {
+			others = append(others, v)
+		}
+	}
+	sort.SliceStable(others, func(a, b int) bool { return inst.Nodes[others[a]].Cost < inst.Nodes[others[b]].Cost })
+	return append([]int{startNode}, others[:inst.K-1]...)
+}
+
+// HilbertStart visits the cost-filtered nodes in Hilbert curve order
+func HilbertStart(inst *Instance, startNode int) ([]int, []bool) {
+	nodes := costFiltered(inst, startNode)
+	key := hilbertKeys(inst, nodes)
+	sort.SliceStable(nodes, func(a, b int) bool { return key[nodes[a]] < key[nodes[b]] })
+	tour := rotateTo(nodes, startNode)
+	return tour, selectionOf(inst.N, tour)
+}
+
+// hilbertKeys returns the Hilbert curve index of every node of nodes over their bounding box
+func hilbertKeys(inst *Instance, nodes []int) []uint64 {
+	minX, minY, maxX, maxY := math.MaxInt, math.MaxInt, math.MinInt, math.MinInt
+	for _, v := range nodes {
+		p := inst.Nodes[v]
+		minX, minY = min(minX, p.X), min(minY, p.Y)
+		maxX, maxY = max(maxX, p.X), max(maxY, p.Y)
+	}
+	side := max(maxX-minX, maxY-minY, 1)
+	scale := float64(int(1)<<hilbertOrder-1) / float64(side)
+	key := make([]uint64, inst.N)
+	for _, v := range nodes {
+		p := inst.Nodes[v]
+		x := uint64(float64(p.X-minX) * scale)
+		y := uint64(float64(p.Y-minY) * scale)
+		key[v] = hilbertIndex(x, y)
+	}
+	return key
+}
+
+// hilbertIndex maps (x, y) of a 2^hilbertOrder grid to the distance along the curve
+func hilbertIndex(x, y uint64) uint64 {
+	var d uint64
+	for s := uint64(1) << (hilbertOrder - 1); s > 0; s /= 2 {
+		var rx, ry uint64
+		if x&s > 0 {
+			rx = 1
+		}
+		if y&s > 0 {
+			ry = 1
+		}
+		d += s * s * ((3 * rx) ^ ry)
+		// rotate the quadrant
+		if ry == 0 {
+			if rx == 1 {
+				x = s - 1 - x&(s-1)
+				y = s - 1 - y&(s-1)
+			}
+			x, y = y, x
+		}
+	}
+	return d
+}
+
+// HullInsertionStart starts from the convex hull of the cost-filtered nodes and inserts
+// the others, outermost first, into the cheapest tour edge next to their Hilbert neighbours
+func HullInsertionStart(inst *Instance, startNode int) ([]int, []bool) {
+	nodes := costFiltered(inst, startNode)
+	key := hilbertKeys(inst, nodes)
+	curve := append([]int{}, nodes...)
+	sort.SliceStable(curve, func(a, b int) bool { return key[curve[a]] < key[curve[b]] })
+	rank := make([]int, inst.N)
+	for r, v := range curve {
+		rank[v] = r
+	}
+
+	tour := convexHull(inst, nodes)
+	next := make([]int, inst.N)
+	prev := make([]int, inst.N)
+	onTour := newRankSet(len(curve))
+	for i, v := range tour {
+		next[v] = tour[(i+1)%len(tour)]
+		prev[next[v]] = v
+		onTour.add(rank[v])
+	}
+	// the remaining nodes by decreasing distance from the centroid, so the tour grows inwards
+	var cx, cy float64
+	for _, v := range nodes {
+		cx += float64(inst.Nodes[v].X) / float64(len(nodes))
+		cy += float64(inst.Nodes[v].Y) / float64(len(nodes))
+	}
+	spread := make([]float64, inst.N)
+	var rest []int
+	for _, v := range nodes {
+		if !onTour.has(rank[v]) {
+			dx, dy := float64(inst.Nodes[v].X)-cx, float64(inst.Nodes[v].Y)-cy
+			spread[v] = dx*dx + dy*dy
+			rest = append(rest, v)
+		}
+	}
+	sort.SliceStable(rest, func(a, b int) bool { return spread[rest[a]] > spread[rest[b]] })
+
+	D := inst.Dist
+	for _, v := range rest {
+		// the edges on both sides of the hullNeighbours nearest tour nodes along the curve
+		bestInc, bestFrom := math.MaxInt, -1
+		c := onTour.count(rank[v])
+		for k := max(1, c-hullNeighbours+1); k <= min(onTour.size, c+hullNeighbours); k++ {
+			p := curve[onTour.kth(k)]
+			for _, a := range [2]int{prev[p], p} {
+				b := next[a]
+				if inc := D[a][v] + D[v][b] - D[a][b]; inc < bestInc {
+					bestInc, bestFrom = inc, a
+				}
+			}
+		}
+		b := next[bestFrom]
+		next[bestFrom], prev[v], next[v], prev[b] = v, bestFrom, b, v
+		onTour.add(rank[v])
+	}
+
+	ordered := make([]int, 0, len(nodes))
+	for v := startNode; len(ordered) < len(nodes); v = next[v] {
+		ordered = append(ordered, v)
+	}
+	return ordered, selectionOf(inst.N, ordered)
+}
+
+// rankSet is a set of ranks 0..n-1 (a Fenwick tree of counts) with O(log n) insertion,
+// counting and selection of the k-th smallest member
+type rankSet struct {
+	tree []int
+	in   []bool
+	size int
+}
+
+func newRankSet(n int) *rankSet {
+	return &rankSet{tree: make([]int, n+1), in: make([]bool, n)}
+}
+
+func (s *rankSet) has(r int) bool { return s.in[r] }
+
+func (s *rankSet) add(r int) {
+	if s.in[r] {
+		return
+	}
+	s.in[r] = true
+	s.size++
+	for i := r + 1; i < len(s.tree); i += i & -i {
+		s.tree[i]++
+	}
+}
+
+// count returns the number of members smaller than r
+func (s *rankSet) count(r int) int {
+	c := 0
+	for i := r; i > 0; i -= i & -i {
+		c += s.tree[i]
+	}
+	return c
+}
+
+// kth returns the k-th smallest member (1-based, k <= size)
+func (s *rankSet) kth(k int) int {
+	pos := 0
+	for step := 1 << bits.Len(uint(len(s.tree)-1)); step > 0; step /= 2 {
+		if pos+step < len(s.tree) && s.tree[pos+step] < k {
+			pos += step
+			k -= s.tree[pos]
+		}
+	}
+	return pos
+}
+
+// convexHull returns the hull of the nodes in counter-clockwise order (Andrew's monotone
+// chain); collinear and duplicate points are left out. With fewer than three distinct
+// points the nodes themselves are returned.
+func convexHull(inst *Instance, nodes []int) []int {
+	pts := append([]int{}, nodes...)
+	sort.SliceStable(pts, func(a, b int) bool {
+		pa, pb := inst.Nodes[pts[a]], inst.Nodes[pts[b]]
+		if pa.X != pb.X {
+			return pa.X < pb.X
+		}
+		return pa.Y < pb.Y
+	})
+	cross := func(o, a, b int) int {
+		po, pa, pb := inst.Nodes[o], inst.Nodes[a], inst.Nodes[b]
+		return (pa.X-po.X)*(pb.Y-po.Y) - (pa.Y-po.Y)*(pb.X-po.X)
+	}
+	var hull []int
+	for pass := 0; pass < 2; pass++ {
+		base := len(hull)
+		for _, p := range pts {
+			for len(hull) >= base+2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+				hull = hull[:len(hull)-1]
+			}
+			hull = append(hull, p)
+		}
+		hull = hull[:len(hull)-1] // the last point starts the other chain
+		for i, j := 0, len(pts)-1; i < j; i, j = i+1, j-1 {
+			pts[i], pts[j] = pts[j], pts[i]
+		}
+	}
+	if len(hull) < 3 {
+		return nodes[:min(len(nodes), 2)]
+	}
+	return hull
+}
+
+// rotateTo returns the cycle starting at node v
+func rotateTo(tour []int, v int) []int {
+	for i, u := range tour {
+		if u == v {
+			return append(append([]int{}, tour[i:]...), tour[:i]...)
+		}
+	}
+	return tour
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// TestRankSet compares count and kth with a sorted slice while members are added in random order
+func TestRankSet(t *testing.T) {
+	for _, n := range []int{1, 2, 7, 64, 100} {
+		s := newRankSet(n)
+		members := make([]bool, n)
+		for _, r := range rand.New(rand.NewSource(int64(n))).Perm(n) {
+			s.add(r)
+			members[r] = true
+			var sorted []int
+			for m, in := range members {
+				if in {
+					sorted = append(sorted, m)
+				}
+			}
+			for k, m := range sorted {
+				if got := s.kth(k + 1); got != m {
+					t.Fatalf("n=%d: kth(%d) = %d, want %d", n, k+1, got, m)
+				}
+				if got := s.count(m); got != k {
+					t.Fatalf("n=%d: count(%d) = %d, want %d", n, m, got, k)
+				}
+			}
+		}
+		if s.size != n {
+			t.Errorf("n=%d: size %d after adding every rank", n, s.size)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// TABU SEARCH
+//
+// Each iteration scans the same neighbourhood as LocalSearchSteepest and applies the
+// best admissible move, even if it is worsening. Tabu attributes are:
+// - edges removed by a move may not be added back,
+// - a node removed from the tour may not be selected again,
+// - a node added to the tour may not be removed again,
+// for the tenure of the move. A tabu move is admissible when it leads to a new best
+// objective (aspiration).
+
+// TabuOptions configures TabuSearch
+type TabuOptions struct {
+	IntraMode  string        // "nodes" or "edges"
+	Tenure     int           // base tenure, 0 = K/4
+	TenureMode string        // one of TenureModes
+	Budget     time.Duration // total running time
+	Iters      int           // exact number of iterations instead of Budget (0 = off)
+}
+
+// TenureModes are the values of TabuOptions.TenureMode
+var TenureModes = []string{"fixed", "random", "reactive"}
+
+// reactive tenure: grow when an objective value is revisited, shrink after a quiet period
+const (
+	tabuReactiveGrow   = 1.2
+	tabuReactiveShrink = 0.9
+)
+
+// TabuMethod wraps TabuSearch as a runnable method starting from random solutions
+func TabuMethod(opts TabuOptions) Method {
+	return Method{
+		Name:      fmt.Sprintf("tabu_intra:%s_tenure:%s", opts.IntraMode, opts.TenureMode),
+		StartType: "random",
+		Improve: func(inst *Instance, tour []int, inSel []bool, rnd *rand.Rand) RunResult {
+			return TabuSearch(inst, tour, inSel, opts, rnd)
+		},
+	}
+}
+
+// tabuList keeps, for every attribute, the iteration until which it is tabu
+type tabuList struct {
+	edge     [][]int // edge (a,b) may not be added
+	addNode  []int   // node may not be selected
+	dropNode []int   // node may not be removed from the tour
+}
+
+func newTabuList(n int) *tabuList {
+	t := &tabuList{edge: make([][]int, n), addNode: make([]int, n), dropNode: make([]int, n)}
+	for i := range t.edge {
+		t.edge[i] = make([]int, n)
+	}
+	return t
+}
+
+// moveEdges lists the edges removed and added by a move (edges present on both sides are left out)
+func moveEdges(tour []int, moveType string, i int, j int) (removed, added [][2]int) {
+	K := len(tour)
+	at := func(p int) int { return tour[mod(p, K)] }
+	switch moveType {
+	case "inter":
+		// i = pos, j = new node
+		removed = [][2]int{{at(i - 1), at(i)}, {at(i), at(i + 1)}}
+		added = [][2]int{{at(i - 1), j}, {j, at(i + 1)}}
+	case "intra_edges":
+		removed = [][2]int{{at(i), at(i + 1)}, {at(j), at(j + 1)}}
+		added = [][2]int{{at(i), at(j)}, {at(i + 1), at(j + 1)}}
+	default: // "intra_nodes"
+		A, B := at(i), at(j)
+		if i == 0 && j == K-1 {
+			removed = [][2]int{{at(j - 1), B}, {A, at(i + 1)}}
+			added = [][2]int{{at(j - 1), A}, {B, at(i + 1)}}
+		} else if j == i+1 {
+			removed = [][2]int{{at(i - 1), A}, {B, at(j + 1)}}
+			added = [][2]int{{at(i - 1), B}, {A, at(j + 1)}}
+		} else {
+			removed = [][2]int{{at(i - 1), A}, {A, at(i + 1)}, {at(j - 1), B}, {B, at(j + 1)}}
+			added = [][2]int{{at(i - 1), B}, {B, at(i + 1)}, {at(j - 1), A}, {A, at(j + 1)}}
+		}
+	}
+	return removed, added
+}
+
+func (t *tabuList) isTabu(tour []int, moveType string, i int, j int, iter int) bool {
+	if moveType == "inter" {
+		if t.addNode[j] > iter || t.dropNode[tour[i]] > iter {
+			return true
+		}
+	}
+	_, added := moveEdges(tour, moveType, i, j)
+	for _, e := range added {
+		if t.edge[e[0]][e[1]] > iter {
+			return true
+		}
+	}
+	return false
+}
+
+// record must be called before the move is applied
+func (t *tabuList) record(tour []int, moveType string, i int, j int, until int) {
+	if moveType == "inter" {
+		t.addNode[tour[i]] = until
+		t.dropNode[j] = until
+	}
+	removed, _ := moveEdges(tour, moveType, i, j)
+	for _, e := range removed {
+		t.edge[e[0]][e[1]] = until
+		t.edge[e[1]][e[0]] = until
+	}
+}
+
+func TabuSearch(inst *Instance, tour []int, inSel []bool, opts TabuOptions, rnd *rand.Rand) RunResult {
+	stop := newBudget(opts.Budget, opts.Iters)
+	res := RunResult{}
+	N, K := inst.N, inst.K
+	dist, nodes := inst.Dist, inst.Nodes
+
+	cur := append([]int{}, tour...)
+	curSel := append([]bool{}, inSel...)
+	curObj := Objective(inst, cur)
+	best := append([]int{}, cur...)
+	bestObj := curObj
+
+	intraType := "intra_edges"
+	if opts.IntraMode == "nodes" {
+		intraType = "intra_nodes"
+	}
+	baseTenure := opts.Tenure
+	if baseTenure <= 0 {
+		baseTenure = K / 4
+	}
+	tenure := float64(baseTenure)
+	lastSeen := map[int]int{} // objective -> iteration (reactive tenure)
+	lastRepeat := 0
+
+	tabu := newTabuList(N)
+
+	for iter := 0; stop.left(res.Iters); iter++ {
+		res.Iters++
+		// best admissible move and best move overall (fallback when everything is tabu)
+		bestDelta, anyDelta := math.MaxInt, math.MaxInt
+		var bestMove, anyMove struct {
+			moveType string
+			i, j     int
+		}
+		consider := func(moveType string, i int, j int, delta int) {
+			res.Evals++
+			if delta < anyDelta {
+				anyDelta = delta
+				anyMove.moveType, anyMove.i, anyMove.j = moveType, i, j
+			}
+			if delta >= bestDelta {
+				return
+			}
+			if tabu.isTabu(cur, moveType, i, j, iter) && curObj+delta >= bestObj {
+				return
+			}
+			bestDelta = delta
+			bestMove.moveType, bestMove.i, bestMove.j = moveType, i, j
+		}
+
+		for i := 0; i < K; i++ {
+			for j := i + 1; j < K; j++ {
+				if intraType == "intra_nodes" {
+					consider(intraType, i, j, deltaSwapPositions(dist, nodes, cur, i, j))
+				} else {
+					consider(intraType, i, j, delta2Opt(dist, nodes, cur, i, j))
+				}
+			}
+		}
+		for pos := 0; pos < K; pos++ {
+			for u := 0; u < N; u++ {
+				if curSel[u] {
+					continue
+				}
+				consider("inter", pos, u, deltaReplaceAtPos(dist, nodes, cur, pos, u))
+			}
+		}
+
+		if bestDelta == math.MaxInt {
+			bestDelta, bestMove = anyDelta, anyMove
+		}
+
+		until := iter + 1
+		switch opts.TenureMode {
+		case "random":
+			until += baseTenure/2 + rnd.Intn(baseTenure+1)
+		default: // "fixed", "reactive"
+			until += int(tenure)
+		}
+		tabu.record(cur, bestMove.moveType, bestMove.i, bestMove.j, until)
+
+		switch bestMove.moveType {
+		case "inter":
+			applyReplace(cur, curSel, bestMove.i, bestMove.j)
+		case "intra_nodes":
+			applySwap(cur, bestMove.i, bestMove.j)
+		default:
+			apply2Opt(cur, bestMove.i, bestMove.j)
+		}
+		curObj += bestDelta
+		if bestDelta < 0 {
+			res.Improvements++
+		}
+		if curObj < bestObj {
+			bestObj = curObj
+			best = append(best[:0], cur...)
+		}
+
+		if opts.TenureMode == "reactive" {
+			if seen, ok := lastSeen[curObj]; ok && iter-seen < 2*int(tenure)+1 {
+				tenure = math.Min(tenure*tabuReactiveGrow+1, float64(K))
+				lastRepeat = iter
+			} else if iter-lastRepeat > K {
+				tenure = math.Max(tenure*tabuReactiveShrink, float64(baseTenure)/2)
+				lastRepeat = iter
+			}
+			lastSeen[curObj] = iter
+		}
+	}
+	res.Tour = best
+	return res
+}
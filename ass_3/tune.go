@@ -0,0 +1,531 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AUTOMATIC PARAMETER TUNING
+//
+// The tuned parameters are the options of the command-line flags: a configuration applies
+// its values to a copy of the base options (Options.With) and the method is rebuilt with
+// BuildMethods exactly as main() builds it, so every option of every -algo (and
+// -walpha/-wbeta of the regret constructions) can be tuned without extra code.
+//
+// All configurations are evaluated block by block; a block is one run on one instance
+// with one seed, shared by all configurations (common random numbers). Blocks are added
+// until -runs runs per instance are done or the time budget is used.
+// - "grid":   the cartesian product of categorical values and Grid points per range,
+// - "random": Configs uniformly sampled configurations,
+// - "race":   like random, but after tuneFirstTest blocks a Friedman test is run after
+//             every block; when it is significant, every configuration a Wilcoxon
+//             signed-rank test finds worse than the best ranked one is dropped (F-race,
+//             as in irace). The budget then goes to the surviving configurations.
+// The base options are always included as configuration 0 ("default").
+
+const (
+	tuneFirstTest    = 5    // blocks before the first race test
+	tuneSignificance = 0.05 // level of the Friedman and Wilcoxon tests
+)
+
+// TuneParam is one tuned flag: categorical Values or a numeric range Lo..Hi
+type TuneParam struct {
+	Name   string
+	Values []string
+	Lo, Hi float64
+	Int    bool // integer range (the flag is an int)
+}
+
+// TuneOptions configures Tune
+type TuneOptions struct {
+	Mode    string        // "grid", "random" or "race"
+	Configs int           // sampled configurations ("random", "race")
+	Grid    int           // points per numeric range ("grid")
+	Runs    int           // largest number of runs per configuration and instance
+	Budget  time.Duration // total tuning time
+	Seed    int64
+	OutPath string // CSV with every evaluation
+}
+
+type tuneConfig struct {
+	ID         int
+	Values     []string // one value per parameter
+	Objs       []int    // objective per evaluated block
+	Eliminated int      // block after which the race dropped it (0 = alive)
+}
+
+type tuneBlock struct {
+	inst int
+	run  int
+	seed int64
+}
+
+// ParseTuneSpace reads "name=v1|v2|...;name=lo:hi;..." where every name is an option of base
+func ParseTuneSpace(spec string, base Options) ([]TuneParam, error) {
+	fs := base.optionSet()
+	var space []TuneParam
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, dom, ok := strings.Cut(part, "=")
+		if !ok || dom == "" {
+			return nil, fmt.Errorf("expected name=values, got %q", part)
+		}
+		if fs.Lookup(name) == nil {
+			return nil, fmt.Errorf("unknown flag %q", name)
+		}
+		p := TuneParam{Name: name}
+		lo, hi, isRange := strings.Cut(dom, ":")
+		if isRange && !strings.Contains(dom, "|") {
+			var err1, err2 error
+			p.Lo, err1 = strconv.ParseFloat(lo, 64)
+			p.Hi, err2 = strconv.ParseFloat(hi, 64)
+			if err1 != nil || err2 != nil || p.Lo > p.Hi {
+				return nil, fmt.Errorf("bad range %q for %s", dom, name)
+			}
+			switch fs.Lookup(name).Value.(flag.Getter).Get().(type) {
+			case int, int64:
+				p.Int = true
+			case float64:
+			default:
+				return nil, fmt.Errorf("-%s is not numeric, list its values as v1|v2|...", name)
+			}
+		} else {
+			p.Values = strings.Split(dom, "|")
+		}
+		space = append(space, p)
+	}
+	if len(space) == 0 {
+		return nil, fmt.Errorf("no parameters")
+	}
+	return space, nil
+}
+
+func (p TuneParam) format(x float64) string {
+	if p.Int {
+		return strconv.Itoa(int(math.Round(x)))
+	}
+	return strconv.FormatFloat(x, 'g', 4, 64)
+}
+
+func (p TuneParam) sample(rnd *rand.Rand) string {
+	if p.Values != nil {
+		return p.Values[rnd.Intn(len(p.Values))]
+	}
+	return p.format(p.Lo + rnd.Float64()*(p.Hi-p.Lo))
+}
+
+// grid returns the categorical values or `points` evenly spaced values of the range
+func (p TuneParam) grid(points int) []string {
+	if p.Values != nil {
+		return p.Values
+	}
+	if points < 2 || p.Lo == p.Hi {
+		return []string{p.format((p.Lo + p.Hi) / 2)}
+	}
+	var out []string
+	for i := 0; i < points; i++ {
+		v := p.format(p.Lo + float64(i)*(p.Hi-p.Lo)/float64(points-1))
+		if len(out) == 0 || out[len(out)-1] != v {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// configFlags prints a configuration as command-line flags
+func configFlags(space []TuneParam, values []string) string {
+	parts := make([]string, len(space))
+	for i, p := range space {
+		parts[i] = fmt.Sprintf("-%s %s", p.Name, values[i])
+	}
+	return strings.Join(parts, " ")
+}
+
+func tuneConfigs(space []TuneParam, base Options, opts TuneOptions, rnd *rand.Rand) [][]string {
+	fs := base.optionSet()
+	def := make([]string, len(space))
+	for i, p := range space {
+		def[i] = fs.Lookup(p.Name).Value.String()
+	}
+	configs := [][]string{def}
+	seen := map[string]bool{strings.Join(def, "\x00"): true}
+	add := func(values []string) {
+		key := strings.Join(values, "\x00")
+		if !seen[key] {
+			seen[key] = true
+			configs = append(configs, values)
+		}
+	}
+	if opts.Mode == "grid" {
+		combos := [][]string{{}}
+		for _, p := range space {
+			var next [][]string
+			for _, c := range combos {
+				for _, v := range p.grid(opts.Grid) {
+					next = append(next, append(append([]string{}, c...), v))
+				}
+			}
+			combos = next
+		}
+		for _, c := range combos {
+			add(c)
+		}
+		return configs
+	}
+	for tries := 0; len(configs) <= opts.Configs && tries < 100*opts.Configs; tries++ {
+		values := make([]string, len(space))
+		for i, p := range space {
+			values[i] = p.sample(rnd)
+		}
+		add(values)
+	}
+	return configs
+}
+
+// Tune evaluates configurations of the options in space, starting from base, and reports
+// the best one
+func Tune(insts []*Instance, names []string, space []TuneParam, base Options, opts TuneOptions) error {
+	if opts.Mode != "grid" && opts.Mode != "random" && opts.Mode != "race" {
+		return fmt.Errorf("unknown tuning mode %q", opts.Mode)
+	}
+	rnd := rand.New(rand.NewSource(opts.Seed))
+	var configs []*tuneConfig
+	for i, values := range tuneConfigs(space, base, opts, rnd) {
+		configs = append(configs, &tuneConfig{ID: i, Values: values})
+	}
+	// building every configuration once makes invalid values fail before any run
+	var name string
+	for _, c := range configs {
+		m, err := applyConfig(space, c, base)
+		if err != nil {
+			return err
+		}
+		name = m.Name
+	}
+
+	var blocks []tuneBlock
+	for run := 0; run < opts.Runs; run++ {
+		for i := range insts {
+			blocks = append(blocks, tuneBlock{inst: i, run: run, seed: rnd.Int63()})
+		}
+	}
+
+	f, err := os.Create(opts.OutPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	defer w.Flush()
+	if err := w.Write([]string{"config", "flags", "instance", "run", "seed", "objective"}); err != nil {
+		return err
+	}
+
+	fmt.Printf("Tuning %s (%s) with %d configurations on %d instance(s), budget %v\n",
+		name, opts.Mode, len(configs), len(insts), opts.Budget)
+	start := time.Now()
+	done := 0
+	for b, blk := range blocks {
+		if b > 0 && time.Since(start) >= opts.Budget {
+			fmt.Printf("Time budget used after %d blocks\n", b)
+			break
+		}
+		for _, c := range configs {
+			if c.Eliminated > 0 {
+				continue
+			}
+			m, err := applyConfig(space, c, base)
+			if err != nil {
+				return err
+			}
+			inst := weighted(insts[blk.inst], m.Weights)
+			runRnd := rand.New(rand.NewSource(blk.seed))
+			tour, inSel := StartSolution(inst, m.StartType, blk.run, runRnd)
+			res := m.Improve(inst, tour, inSel, runRnd)
+			obj := Objective(inst, res.Tour)
+			c.Objs = append(c.Objs, obj)
+			if err := w.Write([]string{
+				strconv.Itoa(c.ID),
+				configFlags(space, c.Values),
+				filepath.Base(names[blk.inst]),
+				strconv.Itoa(blk.run),
+				strconv.FormatInt(blk.seed, 10),
+				strconv.Itoa(obj),
+			}); err != nil {
+				return err
+			}
+		}
+		done = b + 1
+		if opts.Mode == "race" && done >= tuneFirstTest {
+			if n := raceStep(aliveConfigs(configs), done); n > 0 {
+				fmt.Printf("Block %d: dropped %d configuration(s), %d left\n", done, n, len(aliveConfigs(configs)))
+			}
+			if len(aliveConfigs(configs)) == 1 {
+				break
+			}
+		}
+	}
+	reportTuning(space, configs, blocks[:done], names)
+	return w.Error()
+}
+
+// applyConfig builds the method of configuration c on top of base
+func applyConfig(space []TuneParam, c *tuneConfig, base Options) (Method, error) {
+	values := map[string]string{}
+	for j, p := range space {
+		values[p.Name] = c.Values[j]
+	}
+	o, err := base.With(values)
+	if err != nil {
+		return Method{}, fmt.Errorf("config %d: %v", c.ID, err)
+	}
+	ms, err := BuildMethods(o)
+	if err != nil {
+		return Method{}, fmt.Errorf("config %d: %v", c.ID, err)
+	}
+	if len(ms) != 1 {
+		return Method{}, fmt.Errorf("tuning needs an -algo/-starts combination giving one method, got %d", len(ms))
+	}
+	return ms[0], nil
+}
+
+func aliveConfigs(configs []*tuneConfig) []*tuneConfig {
+	var alive []*tuneConfig
+	for _, c := range configs {
+		if c.Eliminated == 0 {
+			alive = append(alive, c)
+		}
+	}
+	return alive
+}
+
+// raceStep runs the Friedman test on the first `blocks` objectives of the alive
+// configurations and drops those significantly worse than the best ranked one
+func raceStep(alive []*tuneConfig, blocks int) int {
+	if len(alive) < 2 {
+		return 0
+	}
+	rankSums := blockRankSums(alive, blocks)
+	best := 0
+	for j := range alive {
+		if rankSums[j] < rankSums[best] {
+			best = j
+		}
+	}
+	if len(alive) > 2 && friedmanP(rankSums, blocks) >= tuneSignificance {
+		return 0
+	}
+	dropped := 0
+	for j, c := range alive {
+		if j == best {
+			continue
+		}
+		p, worse := wilcoxonP(c.Objs[:blocks], alive[best].Objs[:blocks])
+		if worse && p < tuneSignificance {
+			c.Eliminated = blocks
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// blockRankSums ranks the configurations within each block (ties get the average rank)
+func blockRankSums(configs []*tuneConfig, blocks int) []float64 {
+	k := len(configs)
+	sums := make([]float64, k)
+	idx := make([]int, k)
+	for b := 0; b < blocks; b++ {
+		for j := range idx {
+			idx[j] = j
+		}
+		sort.Slice(idx, func(x, y int) bool { return configs[idx[x]].Objs[b] < configs[idx[y]].Objs[b] })
+		for lo := 0; lo < k; {
+			hi := lo
+			for hi+1 < k && configs[idx[hi+1]].Objs[b] == configs[idx[lo]].Objs[b] {
+				hi++
+			}
+			rank := float64(lo+hi)/2 + 1
+			for j := lo; j <= hi; j++ {
+				sums[idx[j]] += rank
+			}
+			lo = hi + 1
+		}
+	}
+	return sums
+}
+
+// friedmanP is the p-value of the Friedman statistic (chi-squared approximation)
+func friedmanP(rankSums []float64, blocks int) float64 {
+	k := float64(len(rankSums))
+	b := float64(blocks)
+	sq := 0.0
+	for _, r := range rankSums {
+		sq += r * r
+	}
+	q := 12/(b*k*(k+1))*sq - 3*b*(k+1)
+	return 1 - gammaP((k-1)/2, q/2)
+}
+
+// wilcoxonP is the two-sided p-value of the Wilcoxon signed-rank test (normal
+// approximation) on the relative differences x/y - 1, and whether x is worse on average
+func wilcoxonP(x, y []int) (float64, bool) {
+	var diffs []float64
+	mean := 0.0
+	for i := range x {
+		d := float64(x[i]-y[i]) / float64(y[i])
+		mean += d
+		if d != 0 {
+			diffs = append(diffs, d)
+		}
+	}
+	worse := mean > 0
+	n := len(diffs)
+	if n == 0 {
+		return 1, false
+	}
+	sort.Slice(diffs, func(a, b int) bool { return math.Abs(diffs[a]) < math.Abs(diffs[b]) })
+	wPlus := 0.0
+	for lo := 0; lo < n; {
+		hi := lo
+		for hi+1 < n && math.Abs(diffs[hi+1]) == math.Abs(diffs[lo]) {
+			hi++
+		}
+		rank := float64(lo+hi)/2 + 1
+		for j := lo; j <= hi; j++ {
+			if diffs[j] > 0 {
+				wPlus += rank
+			}
+		}
+		lo = hi + 1
+	}
+	nf := float64(n)
+	z := (wPlus - nf*(nf+1)/4) / math.Sqrt(nf*(nf+1)*(2*nf+1)/24)
+	return math.Erfc(math.Abs(z) / math.Sqrt2), worse
+}
+
+// gammaP is the regularized lower incomplete gamma function P(a, x)
+func gammaP(a, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	lg, _ := math.Lgamma(a)
+	if x < a+1 {
+		// series
+		sum, term := 1/a, 1/a
+		for n := 1; n < 500; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if term < sum*1e-14 {
+				break
+			}
+		}
+		return sum * math.Exp(-x+a*math.Log(x)-lg)
+	}
+	// continued fraction for Q(a, x) (modified Lentz)
+	tiny := 1e-300
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for i := 1; i < 500; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < 1e-14 {
+			break
+		}
+	}
+	return 1 - math.Exp(-x+a*math.Log(x)-lg)*h
+}
+
+// reportTuning prints every configuration with its mean objective per instance, its
+// mean relative deviation from the best objective of each block and the Wilcoxon p-value
+// against the best configuration on the blocks both evaluated
+func reportTuning(space []TuneParam, configs []*tuneConfig, blocks []tuneBlock, names []string) {
+	if len(blocks) == 0 {
+		fmt.Println("No block evaluated")
+		return
+	}
+	bestInBlock := make([]int, len(blocks))
+	for b := range blocks {
+		bestInBlock[b] = math.MaxInt
+		for _, c := range configs {
+			if b < len(c.Objs) && c.Objs[b] < bestInBlock[b] {
+				bestInBlock[b] = c.Objs[b]
+			}
+		}
+	}
+	dev := make(map[int]float64)
+	for _, c := range configs {
+		for b, obj := range c.Objs {
+			dev[c.ID] += float64(obj-bestInBlock[b]) / float64(bestInBlock[b])
+		}
+		dev[c.ID] /= float64(len(c.Objs))
+	}
+	sorted := append([]*tuneConfig{}, configs...)
+	sort.SliceStable(sorted, func(a, b int) bool {
+		if len(sorted[a].Objs) != len(sorted[b].Objs) {
+			return len(sorted[a].Objs) > len(sorted[b].Objs)
+		}
+		return dev[sorted[a].ID] < dev[sorted[b].ID]
+	})
+	best := sorted[0]
+
+	fmt.Printf("\n%-6s %-6s %-9s", "config", "blocks", "dev%")
+	for _, name := range names {
+		fmt.Printf(" %-12s", "avg "+filepath.Base(name))
+	}
+	fmt.Printf(" %-9s %s\n", "p vs best", "flags")
+	for _, c := range sorted {
+		fmt.Printf("%-6d %-6d %-9.3f", c.ID, len(c.Objs), 100*dev[c.ID])
+		for i := range names {
+			sum, n := 0, 0
+			for b, obj := range c.Objs {
+				if blocks[b].inst == i {
+					sum += obj
+					n++
+				}
+			}
+			if n > 0 {
+				fmt.Printf(" %-12.1f", float64(sum)/float64(n))
+			} else {
+				fmt.Printf(" %-12s", "-")
+			}
+		}
+		p := "-"
+		if c != best {
+			common := min(len(c.Objs), len(best.Objs))
+			pv, _ := wilcoxonP(c.Objs[:common], best.Objs[:common])
+			p = strconv.FormatFloat(pv, 'g', 3, 64)
+		}
+		fmt.Printf(" %-9s %s\n", p, configFlags(space, c.Values))
+	}
+	alive := aliveConfigs(configs)
+	if len(alive) > 2 {
+		fmt.Printf("Friedman test over the %d surviving configurations: p = %.3g\n",
+			len(alive), friedmanP(blockRankSums(alive, len(blocks)), len(blocks)))
+	}
+	fmt.Printf("Best configuration (%d blocks): %s\n", len(best.Objs), configFlags(space, best.Values))
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// VARIABLE NEIGHBOURHOOD DESCENT / SEARCH
+//
+// Neighbourhoods (best improvement inside each):
+// - "swap":    intra node swap (deltaSwapPositions)
+// - "2opt":    intra edge exchange (delta2Opt)
+// - "replace": inter exchange of a selected and an unselected node (deltaReplaceAtPos)
+// - "oropt":   move a segment of 1..orOptMaxLen nodes elsewhere, possibly reversed (deltaOrOpt)
+// VND applies the first neighbourhood until it has no improving move, then tries the
+// next one, and returns to the first after every improvement. VNS shakes the current
+// solution with k random moves, runs VND and restarts from k=1 after an improvement.
+
+// NeighbourhoodNames lists the neighbourhoods usable by VND
+var NeighbourhoodNames = []string{"swap", "2opt", "replace", "oropt"}
+
+const orOptMaxLen = 3
+
+// VNSOptions configures VND and VNS
+type VNSOptions struct {
+	Neighbourhoods []string      // VND order
+	KMax           int           // largest shake (VNS)
+	Budget         time.Duration // total running time (VNS)
+	Iters          int           // exact number of iterations instead of Budget (0 = off)
+}
+
+// ParseNeighbourhoods parses a comma separated neighbourhood order
+func ParseNeighbourhoods(s string) ([]string, error) {
+	var order []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		known := false
+		for _, n := range NeighbourhoodNames {
+			known = known || n == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown neighbourhood %q (available: %s)", name, strings.Join(NeighbourhoodNames, ", "))
+		}
+		order = append(order, name)
+	}
+	return order, nil
+}
+
+// VNDMethod wraps VND as a runnable method starting from random solutions
+func VNDMethod(opts VNSOptions) Method {
+	return Method{
+		Name:      "vnd_" + strings.Join(opts.Neighbourhoods, ">"),
+		StartType: "random",
+		Improve: func(inst *Instance, tour []int, inSel []bool, rnd *rand.Rand) RunResult {
+			t := append([]int{}, tour...)
+			s := append([]bool{}, inSel...)
+			t, evals, imps := VND(inst, t, s, opts.Neighbourhoods)
+			return RunResult{Tour: t, Evals: evals, Improvements: imps}
+		},
+	}
+}
+
+// VNSMethod wraps VNS as a runnable method starting from random solutions
+func VNSMethod(opts VNSOptions) Method {
+	return Method{
+		Name:      fmt.Sprintf("vns_kmax:%d_%s", opts.KMax, strings.Join(opts.Neighbourhoods, ">")),
+		StartType: "random",
+		Improve: func(inst *Instance, tour []int, inSel []bool, rnd *rand.Rand) RunResult {
+			return VNS(inst, tour, inSel, opts, rnd)
+		},
+	}
+}
+
+// VND improves tour/inSel in-place (the tour may be rebuilt by or-opt, so the result must be used)
+func VND(inst *Instance, tour []int, inSel []bool, order []string) ([]int, int, int) {
+	evals, improvements := 0, 0
+	for k := 0; k < len(order); {
+		var improved bool
+		var e int
+		tour, improved, e = bestInNeighbourhood(inst, tour, inSel, order[k])
+		evals += e
+		if improved {
+			improvements++
+			k = 0
+		} else {
+			k++
+		}
+	}
+	return tour, evals, improvements
+}
+
+func VNS(inst *Instance, tour []int, inSel []bool, opts VNSOptions, rnd *rand.Rand) RunResult {
+	stop := newBudget(opts.Budget, opts.Iters)
+	res := RunResult{}
+
+	cur := append([]int{}, tour...)
+	curSel := append([]bool{}, inSel...)
+	cur, evals, imps := VND(inst, cur, curSel, opts.Neighbourhoods)
+	res.Evals += evals
+	res.Improvements += imps
+	curObj := Objective(inst, cur)
+
+	for k := 1; stop.left(res.Iters); {
+		res.Iters++
+		cand := append([]int{}, cur...)
+		candSel := append([]bool{}, curSel...)
+		for s := 0; s < k; s++ {
+			if rnd.Intn(2) == 0 {
+				replaceRandomNodes(inst, cand, candSel, 1, rnd)
+			} else {
+				reverseRandomSegment(cand, rnd)
+			}
+		}
+		cand, evals, imps = VND(inst, cand, candSel, opts.Neighbourhoods)
+		res.Evals += evals
+		res.Improvements += imps
+		res.Restarts++
+		if obj := Objective(inst, cand); obj < curObj {
+			cur, curSel, curObj = cand, candSel, obj
+			k = 1
+		} else if k++; k > opts.KMax {
+			k = 1
+		}
+	}
+	res.Tour = cur
+	return res
+}
+
+// bestInNeighbourhood applies the best improving move of one neighbourhood, if any
+func bestInNeighbourhood(inst *Instance, tour []int, inSel []bool, name string) ([]int, bool, int) {
+	N, K := inst.N, len(tour)
+	dist, nodes := inst.Dist, inst.Nodes
+	evals := 0
+	bestDelta := 0
+	var bi, bj, bk int
+	var brev bool
+
+	switch name {
+	case "swap", "2opt":
+		for i := 0; i < K; i++ {
+			for j := i + 1; j < K; j++ {
+				var delta int
+				if name == "swap" {
+					delta = deltaSwapPositions(dist, nodes, tour, i, j)
+				} else {
+					delta = delta2Opt(dist, nodes, tour, i, j)
+				}
+				evals++
+				if delta < bestDelta {
+					bestDelta, bi, bj = delta, i, j
+				}
+			}
+		}
+		if bestDelta < 0 {
+			if name == "swap" {
+				applySwap(tour, bi, bj)
+			} else {
+				apply2Opt(tour, bi, bj)
+			}
+		}
+	case "replace":
+		for pos := 0; pos < K; pos++ {
+			for u := 0; u < N; u++ {
+				if inSel[u] {
+					continue
+				}
+				delta := deltaReplaceAtPos(dist, nodes, tour, pos, u)
+				evals++
+				if delta < bestDelta {
+					bestDelta, bi, bj = delta, pos, u
+				}
+			}
+		}
+		if bestDelta < 0 {
+			applyReplace(tour, inSel, bi, bj)
+		}
+	case "oropt":
+		for i := 0; i < K; i++ {
+			for L := 1; L <= orOptMaxLen && L < K-2; L++ {
+				// gap m: between the m-th and (m+1)-th node of the rest of the cycle, starting after the segment
+				for m := 0; m < K-L-1; m++ {
+					for _, rev := range []bool{false, true} {
+						delta := deltaOrOpt(dist, tour, i, L, m, rev)
+						evals++
+						if delta < bestDelta {
+							bestDelta, bi, bj, bk, brev = delta, i, L, m, rev
+						}
+					}
+				}
+			}
+		}
+		if bestDelta < 0 {
+			tour = applyOrOpt(tour, bi, bj, bk, brev)
+		}
+	}
+	return tour, bestDelta < 0, evals
+}
+
+// delta for or-opt: move the segment tour[i..i+L-1] (cyclic) between rest[m] and rest[m+1],
+// where rest is the cycle without the segment starting at the node after it
+func deltaOrOpt(dist [][]int, tour []int, i int, L int, m int, reversed bool) int {
+	K := len(tour)
+	s0 := tour[i]
+	sL := tour[(i+L-1)%K]
+	prev := tour[mod(i-1, K)]
+	next := tour[(i+L)%K]
+	a := tour[(i+L+m)%K]
+	b := tour[(i+L+m+1)%K]
+	delta := dist[prev][next] - dist[prev][s0] - dist[sL][next] - dist[a][b]
+	if reversed {
+		delta += dist[a][sL] + dist[s0][b]
+	} else {
+		delta += dist[a][s0] + dist[sL][b]
+	}
+	return delta
+}
+
+// applyOrOpt returns the tour after the or-opt move evaluated by deltaOrOpt
+func applyOrOpt(tour []int, i int, L int, m int, reversed bool) []int {
+	K := len(tour)
+	seg := make([]int, L)
+	for k := range seg {
+		seg[k] = tour[(i+k)%K]
+	}
+	if reversed {
+		for a, b := 0, L-1; a < b; a, b = a+1, b-1 {
+			seg[a], seg[b] = seg[b], seg[a]
+		}
+	}
+	newT := make([]int, 0, K)
+	for k := 0; k < K-L; k++ {
+		newT = append(newT, tour[(i+L+k)%K])
+		if k == m {
+			newT = append(newT, seg...)
+		}
+	}
+	return newT
+}